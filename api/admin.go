@@ -0,0 +1,183 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/levelabs/level-go/collection"
+)
+
+var (
+	errUnauthorized   = errors.New("Admin routes require a valid bearer token")
+	errRequestInvalid = errors.New("Request body is invalid")
+)
+
+// trackRequest is the body of POST /admin/collections. The policy fields
+// default to those of the class, and NotBefore to now.
+type trackRequest struct {
+	Id           string     `json:"id"`
+	Class        string     `json:"class"`
+	Interval     string     `json:"interval"`
+	MaxStaleness string     `json:"max_staleness"`
+	NotBefore    *time.Time `json:"not_before"`
+}
+
+// policy is the refresh policy requested, its durations defaulting to those
+// of its class.
+func (request *trackRequest) policy() (collection.RefreshPolicy, error) {
+	policy := collection.RefreshPolicy{Class: request.Class}
+	if policy.Class == "" {
+		policy.Class = collection.ClassNormal
+	}
+
+	var err error
+	if request.Interval != "" {
+		if policy.Interval, err = time.ParseDuration(request.Interval); err != nil {
+			return policy, err
+		}
+	}
+	if request.MaxStaleness != "" {
+		if policy.MaxStaleness, err = time.ParseDuration(request.MaxStaleness); err != nil {
+			return policy, err
+		}
+	}
+	return policy, policy.Validate()
+}
+
+// EnableAdmin serves the admin routes, managing the waitlist of manager for
+// requests bearing token:
+//
+//	GET    /admin/collections
+//	POST   /admin/collections
+//	GET    /admin/collections/{asset}
+//	DELETE /admin/collections/{asset}
+//	POST   /admin/collections/{asset}/pause
+//	POST   /admin/collections/{asset}/resume
+//	POST   /admin/collections/{asset}/sequence
+//	POST   /admin/collections/{asset}/requeue
+func (server *Server) EnableAdmin(manager *collection.Manager, token string) {
+	server.manager = manager
+	server.adminToken = token
+
+	server.mux.HandleFunc("/admin/collections", server.authorize(server.handleAdminCollections))
+	server.mux.HandleFunc("/admin/collections/", server.authorize(server.handleAdminCollection))
+}
+
+// authorize only lets through the requests bearing the admin token.
+func (server *Server) authorize(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if server.adminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(server.adminToken)) != 1 {
+			writeError(w, errUnauthorized)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+func (server *Server) handleAdminCollections(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		statuses, err := server.manager.Statuses()
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, statuses)
+	case http.MethodPost:
+		server.trackCollection(w, r)
+	default:
+		writeError(w, errMethodNotAllowed)
+	}
+}
+
+func (server *Server) handleAdminCollection(w http.ResponseWriter, r *http.Request) {
+	parts := pathParts(r.URL.Path, "/admin/collections/")
+	if len(parts) == 0 {
+		writeError(w, errRouteNotFound)
+		return
+	}
+	assetId, err := collection.NormalizeAssetId(parts[0])
+	if err != nil {
+		writeError(w, errRouteNotFound)
+		return
+	}
+
+	if len(parts) == 1 {
+		switch r.Method {
+		case http.MethodGet:
+			server.writeStatus(w, http.StatusOK)(server.manager.Status(assetId))
+		case http.MethodDelete:
+			if err := server.manager.Untrack(assetId); err != nil {
+				writeError(w, err)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, errMethodNotAllowed)
+		}
+		return
+	}
+
+	if len(parts) != 2 {
+		writeError(w, errRouteNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, errMethodNotAllowed)
+		return
+	}
+
+	switch parts[1] {
+	case "pause":
+		server.writeStatus(w, http.StatusOK)(server.manager.Pause(assetId))
+	case "resume":
+		server.writeStatus(w, http.StatusOK)(server.manager.Resume(assetId))
+	case "sequence":
+		server.writeStatus(w, http.StatusAccepted)(server.manager.Resequence(assetId))
+	case "requeue":
+		server.writeStatus(w, http.StatusOK)(server.manager.Requeue(assetId))
+	default:
+		writeError(w, errRouteNotFound)
+	}
+}
+
+func (server *Server) trackCollection(w http.ResponseWriter, r *http.Request) {
+	var request trackRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, errRequestInvalid)
+		return
+	}
+	policy, err := request.policy()
+	if err != nil {
+		writeError(w, errRequestInvalid)
+		return
+	}
+	if _, err := collection.NormalizeAssetId(request.Id); err != nil {
+		writeError(w, errRequestInvalid)
+		return
+	}
+
+	notBefore := time.Now()
+	if request.NotBefore != nil {
+		notBefore = *request.NotBefore
+	}
+
+	server.writeStatus(w, http.StatusCreated)(server.manager.Track(request.Id, policy, notBefore))
+}
+
+// writeStatus writes the job status returned by a manager call, or its
+// error.
+func (server *Server) writeStatus(w http.ResponseWriter, code int) func(*collection.JobStatus, error) {
+	return func(status *collection.JobStatus, err error) {
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, code, status)
+	}
+}
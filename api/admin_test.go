@@ -0,0 +1,62 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/levelabs/level-go/collection"
+)
+
+const testAdminToken = "secret"
+
+func newTestAdmin(t *testing.T) *Server {
+	server, s := newTestServer(t)
+	manager := &collection.Manager{
+		Connection: &collection.Client{Chains: map[uint64]*collection.Ethereum{
+			collection.ChainMainnet: {ChainId: collection.ChainMainnet},
+		}},
+		Waitlist: collection.NewWaitlist(&collection.PriorityQueue{}),
+		Store:    s,
+	}
+	server.EnableAdmin(manager, testAdminToken)
+	return server
+}
+
+func adminRequest(server *Server, method string, path string, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	r.Header.Set("Authorization", "Bearer "+testAdminToken)
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, r)
+	return w
+}
+
+func TestTrackCollection(t *testing.T) {
+	server := newTestAdmin(t)
+
+	tests := []struct {
+		body   string
+		status int
+	}{
+		{`{"id":"1:0x0000000000000000000000000000000000000001"}`, http.StatusCreated},
+		{`{"id":"0x0000000000000000000000000000000000000002","class":"hot"}`, http.StatusCreated},
+		{`{"id":"999:0x0000000000000000000000000000000000000001"}`, http.StatusBadRequest},
+		{`{"id":"1:0x01","class":"hot"}`, http.StatusBadRequest},
+		{`{"id":"1:0x0000000000000000000000000000000000000003","class":"lukewarm"}`, http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		if w := adminRequest(server, http.MethodPost, "/admin/collections", test.body); w.Code != test.status {
+			t.Errorf("POST %s = %d %s, want %d", test.body, w.Code, w.Body, test.status)
+		}
+	}
+}
+
+func TestRequeueCollection(t *testing.T) {
+	server := newTestAdmin(t)
+
+	w := adminRequest(server, http.MethodPost, "/admin/collections/1:0x0000000000000000000000000000000000000001/requeue", "")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("requeue of a live collection = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/dgraph-io/ristretto"
+
+	"github.com/levelabs/level-go/collection"
+	"github.com/levelabs/level-go/store"
+)
+
+var (
+	errRouteNotFound    = errors.New("Route doesn't exist")
+	errMethodNotAllowed = errors.New("Method isn't allowed on this route")
+)
+
+// Server serves the indexed collections over HTTP, reading from the store
+// and keeping derived results, such as rarity ranks, in the cache.
+type Server struct {
+	store *store.Store
+	cache *ristretto.Cache
+
+	// the admin routes are only served once enabled
+	manager    *collection.Manager
+	adminToken string
+
+	mux *http.ServeMux
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func NewServer(store *store.Store, cache *ristretto.Cache) *Server {
+	server := Server{
+		store: store,
+		cache: cache,
+		mux:   http.NewServeMux(),
+	}
+
+	server.mux.HandleFunc("/collections", server.handleCollections)
+	server.mux.HandleFunc("/collections/", server.handleCollection)
+
+	return &server
+}
+
+func (server *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	server.mux.ServeHTTP(w, r)
+}
+
+// Invalidate drops the cached results of a collection, to be called once
+// it has been sequenced again.
+func (server *Server) Invalidate(assetId string) {
+	server.cache.Del(cacheKey("asset", assetId))
+	server.cache.Del(cacheKey("rarity", assetId))
+}
+
+func cacheKey(kind string, assetId string) string {
+	return kind + "/" + strings.ToLower(assetId)
+}
+
+// pathParts splits the path below prefix into its segments.
+func pathParts(path string, prefix string) []string {
+	trimmed := strings.Trim(strings.TrimPrefix(path, prefix), "/")
+	if trimmed == "" {
+		return nil
+	}
+	return strings.Split(trimmed, "/")
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Print("[ERROR]: Writing response", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch err {
+	case store.ErrNotFound, errRouteNotFound, collection.ErrAssetNotTracked, collection.ErrImageNotInline,
+		collection.ErrDeadLetterNotFound:
+		status = http.StatusNotFound
+	case errMethodNotAllowed:
+		status = http.StatusMethodNotAllowed
+	case errUnauthorized:
+		status = http.StatusUnauthorized
+	case errRequestInvalid, collection.ErrChainNotConfigured:
+		status = http.StatusBadRequest
+	case collection.ErrAssetRunning, collection.ErrAssetPaused:
+		status = http.StatusConflict
+	case collection.ErrImageTypeUnsupported:
+		status = http.StatusUnsupportedMediaType
+	}
+	writeJSON(w, status, &errorResponse{Error: err.Error()})
+}
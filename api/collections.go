@@ -0,0 +1,436 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/levelabs/level-go/collection"
+)
+
+var (
+	errTraitFilterMissing = errors.New("Filtering tokens requires a trait")
+	errLimitInvalid       = errors.New("Limit should be a positive number")
+)
+
+// Page sizes of the token listing, when none is asked for and at most.
+const (
+	defaultTokenLimit = 100
+	maxTokenLimit     = 1000
+)
+
+// svgPolicy keeps an inline SVG from running scripts or loading anything.
+const svgPolicy = "default-src 'none'; style-src 'unsafe-inline'"
+
+type collectionResponse struct {
+	Id           string                  `json:"id"`
+	ChainId      uint64                  `json:"chain_id"`
+	Address      string                  `json:"address"`
+	Capabilities collection.Capabilities `json:"capabilities"`
+	Uri          *collection.Uri         `json:"uri,omitempty"`
+	TotalSupply  string                  `json:"total_supply"`
+	Tokens       int                     `json:"tokens"`
+	Coverage     float64                 `json:"coverage"`
+
+	// State is where the collection stands on the waitlist, empty once
+	// it's no longer tracked.
+	State      string `json:"state,omitempty"`
+	LastResult string `json:"last_result,omitempty"`
+}
+
+type valueResponse struct {
+	Count     float64 `json:"count"`
+	Frequency float64 `json:"frequency"`
+}
+
+// numericResponse is the range and histogram of a numeric trait.
+type numericResponse struct {
+	DisplayType string              `json:"display_type,omitempty"`
+	Min         float64             `json:"min"`
+	Max         float64             `json:"max"`
+	Mean        float64             `json:"mean"`
+	MaxValue    float64             `json:"max_value,omitempty"`
+	Count       float64             `json:"count"`
+	Histogram   []collection.Bucket `json:"histogram"`
+}
+
+type traitsResponse struct {
+	Id       string                              `json:"id"`
+	Address  string                              `json:"address"`
+	Tokens   int                                 `json:"tokens"`
+	Coverage float64                             `json:"coverage"`
+	Traits   map[string]map[string]valueResponse `json:"traits"`
+	Numeric  map[string]numericResponse          `json:"numeric"`
+}
+
+// tokensResponse is a page of tokens. Cursor is set when more tokens follow,
+// to be passed back for the next page.
+type tokensResponse struct {
+	Tokens []*collection.TokenRecord `json:"tokens"`
+	Cursor string                    `json:"cursor,omitempty"`
+}
+
+type tokenResponse struct {
+	Token  *collection.TokenRecord     `json:"token"`
+	Rarity map[string]collection.Score `json:"rarity"`
+}
+
+// rarityIndex holds the score of every token under every scorer, by scorer
+// name and then token id.
+type rarityIndex map[string]map[string]collection.Score
+
+// handleCollections serves GET /collections: every stored, tracked or
+// dead-lettered collection, by asset id. Tracked collections which haven't
+// been sequenced yet are listed without supply nor tokens.
+func (server *Server) handleCollections(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, errMethodNotAllowed)
+		return
+	}
+
+	assets, err := collection.LoadAssets(server.store)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	entries, err := collection.LoadWaitlist(server.store)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	letters, err := collection.LoadDeadLetters(server.store)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	byId := make(map[string]*collectionResponse, len(assets)+len(entries))
+	for _, asset := range assets {
+		response := newCollectionResponse(asset)
+		byId[response.Id] = &response
+	}
+	listed := func(assetId string) (*collectionResponse, error) {
+		if response, ok := byId[assetId]; ok {
+			return response, nil
+		}
+		chainId, address, err := collection.ParseAssetId(assetId)
+		if err != nil {
+			return nil, err
+		}
+		response := newCollectionResponse(collection.NewAsset(chainId, address, 0, 0))
+		byId[assetId] = &response
+		return &response, nil
+	}
+
+	for _, entry := range entries {
+		response, err := listed(entry.Collection)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		response.State = server.state(entry)
+		response.LastResult = entry.LastResult
+	}
+	for _, letter := range letters {
+		response, err := listed(letter.Collection)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		response.State = collection.StateDead
+		response.LastResult = collection.ResultFailed
+	}
+
+	collections := make([]*collectionResponse, 0, len(byId))
+	for _, response := range byId {
+		collections = append(collections, response)
+	}
+	sort.Slice(collections, func(i, j int) bool {
+		return collections[i].Id < collections[j].Id
+	})
+	writeJSON(w, http.StatusOK, collections)
+}
+
+// state is where a tracked collection stands. Only the manager knows which
+// collections are running, so without the admin routes a collection which
+// isn't paused is reported queued.
+func (server *Server) state(entry *collection.WaitlistEntry) string {
+	if server.manager != nil {
+		if status, err := server.manager.Status(entry.Collection); err == nil {
+			return status.State
+		}
+	}
+	if entry.Paused {
+		return collection.StatePaused
+	}
+	return collection.StateQueued
+}
+
+// handleCollection serves the routes below /collections/{asset}, where asset
+// is a "<chain id>:<address>" asset id or a bare mainnet address:
+//
+//	GET /collections/{asset}
+//	GET /collections/{asset}/traits
+//	GET /collections/{asset}/tokens?trait=&value=&limit=&cursor=
+//	GET /collections/{asset}/tokens/{id}
+//	GET /collections/{asset}/tokens/{id}/image
+func (server *Server) handleCollection(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, errMethodNotAllowed)
+		return
+	}
+
+	parts := pathParts(r.URL.Path, "/collections/")
+	if len(parts) == 0 {
+		writeError(w, errRouteNotFound)
+		return
+	}
+	assetId, err := collection.NormalizeAssetId(parts[0])
+	if err != nil {
+		writeError(w, errRouteNotFound)
+		return
+	}
+
+	switch {
+	case len(parts) == 1:
+		server.getCollection(w, assetId)
+	case len(parts) == 2 && parts[1] == "traits":
+		server.getTraits(w, assetId)
+	case len(parts) == 2 && parts[1] == "tokens":
+		server.filterTokens(w, r, assetId)
+	case len(parts) == 3 && parts[1] == "tokens":
+		server.getToken(w, assetId, parts[2])
+	case len(parts) == 4 && parts[1] == "tokens" && parts[3] == "image":
+		server.getTokenImage(w, assetId, parts[2])
+	default:
+		writeError(w, errRouteNotFound)
+	}
+}
+
+func (server *Server) getCollection(w http.ResponseWriter, assetId string) {
+	asset, err := server.loadAsset(assetId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newCollectionResponse(asset))
+}
+
+func (server *Server) getTraits(w http.ResponseWriter, assetId string) {
+	asset, err := server.loadAsset(assetId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	response := traitsResponse{
+		Id:      asset.Id(),
+		Address: asset.Address(),
+		Traits:  make(map[string]map[string]valueResponse),
+		Numeric: make(map[string]numericResponse),
+	}
+
+	if trait := asset.Trait(); trait != nil {
+		response.Tokens = trait.Index
+		response.Coverage = trait.Coverage()
+		for category := range trait.Counter {
+			values := make(map[string]valueResponse)
+			for value, count := range trait.Values(category) {
+				values[value] = valueResponse{
+					Count:     count,
+					Frequency: trait.Frequency(category, value),
+				}
+			}
+			response.Traits[category] = values
+		}
+		for category, numeric := range trait.Numeric {
+			response.Numeric[category] = numericResponse{
+				DisplayType: numeric.DisplayType,
+				Min:         numeric.Min,
+				Max:         numeric.Max,
+				Mean:        numeric.Mean(),
+				MaxValue:    numeric.MaxValue,
+				Count:       numeric.Count,
+				Histogram:   numeric.Histogram(collection.HistogramBuckets),
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, &response)
+}
+
+func (server *Server) getToken(w http.ResponseWriter, assetId string, id string) {
+	record, err := collection.LoadToken(server.store, assetId, id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	ranks, err := server.loadRarity(assetId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	response := tokenResponse{
+		Token:  record,
+		Rarity: make(map[string]collection.Score),
+	}
+	for scorer, scores := range ranks {
+		if score, ok := scores[id]; ok {
+			response.Rarity[scorer] = score
+		}
+	}
+	writeJSON(w, http.StatusOK, &response)
+}
+
+// getTokenImage serves the image inlined in the metadata of a token, such as
+// the SVG of an on-chain collection. The content comes from the contract, so
+// SVGs are served under a policy forbidding scripts and any other fetch.
+func (server *Server) getTokenImage(w http.ResponseWriter, assetId string, id string) {
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	record, err := collection.LoadToken(server.store, assetId, id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	image, mediaType, err := record.InlineImage()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", mediaType)
+	if mediaType == collection.MediaTypeSVG {
+		w.Header().Set("Content-Security-Policy", svgPolicy)
+	}
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(image); err != nil {
+		log.Print("[ERROR]: Writing response", err)
+	}
+}
+
+// filterTokens returns a page of the tokens holding a trait category,
+// narrowed down to a single value when one is given. Tokens come in the
+// order they are stored, and a page starts after the token id in cursor.
+func (server *Server) filterTokens(w http.ResponseWriter, r *http.Request, assetId string) {
+	query := r.URL.Query()
+	category := query.Get("trait")
+	if category == "" {
+		writeJSON(w, http.StatusBadRequest, &errorResponse{Error: errTraitFilterMissing.Error()})
+		return
+	}
+	value, byValue := query["value"]
+	cursor := query.Get("cursor")
+
+	limit := defaultTokenLimit
+	if text := query.Get("limit"); text != "" {
+		parsed, err := strconv.Atoi(text)
+		if err != nil || parsed <= 0 {
+			writeJSON(w, http.StatusBadRequest, &errorResponse{Error: errLimitInvalid.Error()})
+			return
+		}
+		limit = parsed
+		if limit > maxTokenLimit {
+			limit = maxTokenLimit
+		}
+	}
+
+	records, err := collection.LoadTokenRecords(server.store, assetId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// one token past the page tells whether another one follows
+	page := tokensResponse{Tokens: []*collection.TokenRecord{}}
+	for _, record := range records {
+		if len(page.Tokens) > limit {
+			break
+		}
+		// records are in key order, which is the byte order of their ids
+		if cursor != "" && record.Id <= cursor {
+			continue
+		}
+		for _, attribute := range record.Attributes {
+			if attribute.Trait != category {
+				continue
+			}
+			if byValue && attribute.Value.String() != value[0] {
+				continue
+			}
+			page.Tokens = append(page.Tokens, record)
+			break
+		}
+	}
+	if len(page.Tokens) > limit {
+		page.Tokens = page.Tokens[:limit]
+		page.Cursor = page.Tokens[limit-1].Id
+	}
+	writeJSON(w, http.StatusOK, &page)
+}
+
+func (server *Server) loadAsset(assetId string) (*collection.Asset, error) {
+	key := cacheKey("asset", assetId)
+	if cached, ok := server.cache.Get(key); ok {
+		return cached.(*collection.Asset), nil
+	}
+
+	asset, err := collection.LoadAsset(server.store, assetId)
+	if err != nil {
+		return nil, err
+	}
+	server.cache.Set(key, asset, 1)
+	return asset, nil
+}
+
+// loadRarity ranks the stored tokens of a collection under every scorer. The
+// ranks are cached until the collection is invalidated.
+func (server *Server) loadRarity(assetId string) (rarityIndex, error) {
+	key := cacheKey("rarity", assetId)
+	if cached, ok := server.cache.Get(key); ok {
+		return cached.(rarityIndex), nil
+	}
+
+	asset, err := server.loadAsset(assetId)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := collection.LoadTokens(server.store, assetId)
+	if err != nil {
+		return nil, err
+	}
+
+	ranks := make(rarityIndex)
+	if trait := asset.Trait(); trait != nil {
+		for scorer, scores := range collection.Compare(trait, tokens) {
+			byId := make(map[string]collection.Score, len(scores))
+			for _, score := range scores {
+				byId[score.Id] = score
+			}
+			ranks[scorer] = byId
+		}
+	}
+
+	server.cache.Set(key, ranks, int64(len(tokens)))
+	return ranks, nil
+}
+
+func newCollectionResponse(asset *collection.Asset) collectionResponse {
+	response := collectionResponse{
+		Id:           asset.Id(),
+		ChainId:      asset.ChainId(),
+		Address:      asset.Address(),
+		Capabilities: asset.Capabilities(),
+		Uri:          asset.Uri(),
+		TotalSupply:  asset.TotalSupply().String(),
+	}
+	if trait := asset.Trait(); trait != nil {
+		response.Tokens = trait.Index
+		response.Coverage = trait.Coverage()
+	}
+	return response
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	badger "github.com/dgraph-io/badger/v3"
+	"github.com/dgraph-io/ristretto"
+
+	"github.com/levelabs/level-go/collection"
+	"github.com/levelabs/level-go/store"
+)
+
+const testAssetId = "1:0x0000000000000000000000000000000000000001"
+
+func newTestServer(t *testing.T) (*Server, *store.Store) {
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	cache, err := ristretto.NewCache(&ristretto.Config{NumCounters: 1e3, MaxCost: 1e3, BufferItems: 64})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := store.NewStore(db)
+	return NewServer(s, cache), s
+}
+
+func TestGetTokenImage(t *testing.T) {
+	server, s := newTestServer(t)
+
+	tests := []struct {
+		image       string
+		status      int
+		contentType string
+		policy      string
+	}{
+		{"data:image/png;base64,iVBORw0KGgo=", http.StatusOK, "image/png", ""},
+		{"data:image/svg+xml;utf8,<svg/>", http.StatusOK, collection.MediaTypeSVG, svgPolicy},
+		{"data:text/html,<script>alert(1)</script>", http.StatusUnsupportedMediaType, "application/json", ""},
+		{"data:image/svg+xml;base64,!!!", http.StatusInternalServerError, "application/json", ""},
+		{"https://example.com/1.png", http.StatusNotFound, "application/json", ""},
+	}
+
+	for i, test := range tests {
+		id := string(rune('a' + i))
+		record := collection.TokenRecord{Collection: testAssetId, Id: id}
+		record.Image = test.image
+		if err := s.Set(store.TokenKey(testAssetId, id), &record); err != nil {
+			t.Fatal(err)
+		}
+
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/collections/"+testAssetId+"/tokens/"+id+"/image", nil))
+
+		if w.Code != test.status {
+			t.Errorf("%s: status = %d, want %d", test.image, w.Code, test.status)
+		}
+		if got := w.Header().Get("Content-Type"); got != test.contentType {
+			t.Errorf("%s: Content-Type = %s, want %s", test.image, got, test.contentType)
+		}
+		if got := w.Header().Get("Content-Security-Policy"); got != test.policy {
+			t.Errorf("%s: Content-Security-Policy = %s, want %s", test.image, got, test.policy)
+		}
+		if got := w.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+			t.Errorf("%s: X-Content-Type-Options = %s, want nosniff", test.image, got)
+		}
+	}
+}
+
+func TestListCollections(t *testing.T) {
+	server, s := newTestServer(t)
+
+	const (
+		tracked = "137:0x0000000000000000000000000000000000000002"
+		dead    = "1:0x0000000000000000000000000000000000000003"
+	)
+	if err := collection.SaveAsset(s, collection.NewAsset(collection.ChainMainnet, "0x0000000000000000000000000000000000000001", 0, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := collection.TrackAsset(s, tracked, collection.DefaultRefreshPolicy()); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set(store.DeadLetterKey(dead), &collection.DeadLetter{Collection: dead, Policy: collection.DefaultRefreshPolicy()}); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/collections", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	var collections []collectionResponse
+	if err := json.NewDecoder(w.Body).Decode(&collections); err != nil {
+		t.Fatal(err)
+	}
+
+	got := make([]string, len(collections))
+	for i, response := range collections {
+		got[i] = response.Id + " " + response.State
+	}
+	want := fmt.Sprint([]string{
+		tracked + " " + collection.StateQueued,
+		testAssetId + " ",
+		dead + " " + collection.StateDead,
+	})
+	if fmt.Sprint(got) != want {
+		t.Errorf("collections = %v, want %s", got, want)
+	}
+}
+
+func TestFilterTokensPages(t *testing.T) {
+	server, s := newTestServer(t)
+
+	// tokens 1 to 5 are Gold but 3, stored in the byte order of their ids
+	for i := 1; i <= 5; i++ {
+		value := "Gold"
+		if i == 3 {
+			value = "Silver"
+		}
+		var record collection.TokenRecord
+		encoded := fmt.Sprintf(`{"collection":"%s","id":"%d","attributes":[{"trait_type":"Fur","value":"%s"}]}`, testAssetId, i, value)
+		if err := json.Unmarshal([]byte(encoded), &record); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Set(store.TokenKey(testAssetId, record.Id), &record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	page := func(query string) (int, tokensResponse) {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/collections/"+testAssetId+"/tokens?"+query, nil))
+		var response tokensResponse
+		if w.Code == http.StatusOK {
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return w.Code, response
+	}
+	ids := func(response tokensResponse) string {
+		ids := make([]string, len(response.Tokens))
+		for i, token := range response.Tokens {
+			ids[i] = token.Id
+		}
+		return fmt.Sprint(ids)
+	}
+
+	tests := []struct {
+		query  string
+		ids    string
+		cursor string
+	}{
+		{"trait=Fur&value=Gold&limit=2", "[1 2]", "2"},
+		{"trait=Fur&value=Gold&limit=2&cursor=2", "[4 5]", ""},
+		{"trait=Fur&value=Gold&limit=3&cursor=1", "[2 4 5]", ""},
+		{"trait=Fur", "[1 2 3 4 5]", ""},
+	}
+	for _, test := range tests {
+		status, response := page(test.query)
+		if status != http.StatusOK {
+			t.Errorf("%s: status = %d, want %d", test.query, status, http.StatusOK)
+			continue
+		}
+		if got := ids(response); got != test.ids || response.Cursor != test.cursor {
+			t.Errorf("%s: page = %s cursor %q, want %s cursor %q", test.query, got, response.Cursor, test.ids, test.cursor)
+		}
+	}
+
+	for _, query := range []string{"trait=Fur&limit=0", "trait=Fur&limit=many"} {
+		if status, _ := page(query); status != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want %d", query, status, http.StatusBadRequest)
+		}
+	}
+}
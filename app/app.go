@@ -0,0 +1,133 @@
+package app
+
+import (
+	"context"
+	"errors"
+	badger "github.com/dgraph-io/badger/v3"
+	"github.com/dgraph-io/ristretto"
+	"github.com/go-co-op/gocron"
+	"github.com/levelabs/level-go/api"
+	"github.com/levelabs/level-go/collection"
+	"github.com/levelabs/level-go/config"
+	s "github.com/levelabs/level-go/scheduler"
+	"github.com/levelabs/level-go/store"
+	"log"
+	"net/http"
+)
+
+var (
+	errManagerFailed = errors.New("Manager failed to start")
+)
+
+// App runs the sequencing workers, the ownership indexing and the API over
+// one store.
+type App struct {
+	config    *config.Config
+	scheduler *gocron.Scheduler
+	manager   *collection.Manager
+
+	cache *ristretto.Cache
+	db    *badger.DB
+	store *store.Store
+
+	api       *api.Server
+	workers   *collection.WorkerPool
+	owners    *collection.OwnershipStream
+	following map[string]bool
+}
+
+func NewApp(conf *config.Config) *App {
+	scheduler := s.NewScheduler()
+
+	cache, err := ristretto.NewCache(&ristretto.Config{
+		NumCounters: conf.Cache.Counters,
+		MaxCost:     conf.Cache.MaxCost,
+		BufferItems: conf.Cache.BufferItems,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db, err := badger.Open(badger.DefaultOptions(conf.Storage.Path))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	store := store.NewStore(db)
+	if err := collection.MigrateLegacyAssets(store); err != nil {
+		log.Fatal(err)
+	}
+
+	manager, err := collection.NewManager(conf.ClientConfig(), conf.Seeds(), store)
+	if err != nil {
+		log.Fatal(errManagerFailed)
+	}
+	manager.Crawl = conf.CrawlConfig()
+	manager.Supply.DeployBlocks = conf.DeployBlocks()
+	manager.TokenRanges = conf.TokenRanges()
+
+	workers := collection.NewWorkerPool(manager, conf.Workers.Sequence)
+	workers.IdleWait = conf.Schedule.Idle
+
+	indexer := collection.NewOwnershipIndexer(manager.Connection, store)
+	indexer.DeployBlocks = conf.DeployBlocks()
+
+	server := api.NewServer(store, cache)
+	if conf.Admin.Token != "" {
+		server.EnableAdmin(manager, conf.Admin.Token)
+	}
+
+	app := App{
+		config:    conf,
+		scheduler: scheduler,
+		manager:   manager,
+		cache:     cache,
+		db:        db,
+		store:     store,
+		api:       server,
+		workers:   workers,
+		owners:    collection.NewOwnershipStream(manager.Connection, indexer),
+		following: make(map[string]bool),
+	}
+
+	return &app
+}
+
+func (app *App) Schedule() {
+	go app.workers.Run(context.Background(), func(asset *collection.Asset) {
+		log.Printf("[SUCCESS]: Asset completed sequencing %s", asset)
+		app.api.Invalidate(asset.Id())
+	})
+
+	app.scheduler.Every(app.config.Schedule.Ownership).SingletonMode().Do(func() {
+		assets, err := collection.LoadAssets(app.store)
+		if err != nil {
+			log.Print("[ERROR]: Issue with DB", err)
+			return
+		}
+
+		for _, asset := range assets {
+			// ERC-1155 holders are tracked by the supply indexer instead
+			if asset.Standard() == collection.StandardERC1155 {
+				continue
+			}
+			if app.following[asset.Id()] {
+				continue
+			}
+			app.following[asset.Id()] = true
+
+			go func(asset *collection.Asset) {
+				if err := app.owners.Follow(context.Background(), asset); err != nil {
+					log.Print("[WARN]: Indexing owners", err)
+				}
+			}(asset)
+		}
+	})
+
+	s.Start(app.scheduler)
+}
+
+// Serve runs the API until the listener fails.
+func (app *App) Serve() error {
+	return http.ListenAndServe(app.config.Listen, app.api)
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/levelabs/level-go/collection"
+	"github.com/spf13/cobra"
+)
+
+var index = &cobra.Command{
+	Use:   "index <address>",
+	Short: "Sequence one collection once, store it and print its traits",
+	Long: "Sequence one collection once, store it and print its traits. The\n" +
+		"address is a \"<chain id>:<address>\" asset id or a bare mainnet address.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+
+		manager, err := collection.NewManager(conf.ClientConfig(), nil, s)
+		if err != nil {
+			return err
+		}
+		manager.Crawl = conf.CrawlConfig()
+		manager.Supply.DeployBlocks = conf.DeployBlocks()
+		manager.TokenRanges = conf.TokenRanges()
+
+		asset, err := manager.Index(args[0])
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), asset)
+		return printTraits(cmd.OutOrStdout(), asset.Trait())
+	},
+}
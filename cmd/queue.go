@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/levelabs/level-go/collection"
+	"github.com/levelabs/level-go/config"
+	"github.com/spf13/cobra"
+	"text/tabwriter"
+	"time"
+)
+
+var queue = &cobra.Command{
+	Use:   "queue",
+	Short: "Manage the waitlist of tracked collections",
+}
+
+var queueList = &cobra.Command{
+	Use:   "list",
+	Short: "List the tracked collections, or the dead-lettered ones with --dead",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+
+		if dead, _ := cmd.Flags().GetBool("dead"); dead {
+			letters, err := collection.LoadDeadLetters(s)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(w, "COLLECTION\tATTEMPTS\tKIND\tDEAD AT\tERROR")
+			for _, letter := range letters {
+				fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n",
+					letter.Collection, letter.Attempts, letter.Kind, letter.DeadAt.Format(time.RFC3339), letter.Error)
+			}
+			return w.Flush()
+		}
+
+		entries, err := collection.LoadWaitlist(s)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, "COLLECTION\tCLASS\tINTERVAL\tDUE\tATTEMPTS\tLAST RESULT")
+		for _, entry := range entries {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n",
+				entry.Collection, entry.Policy.Class, entry.Policy.Interval,
+				time.Unix(0, entry.Priority).Format(time.RFC3339), entry.Attempts, entry.LastResult)
+		}
+		return w.Flush()
+	},
+}
+
+var queueAdd = &cobra.Command{
+	Use:   "add <address>",
+	Short: "Track a collection, due right away, taking it off the dead-letter list",
+	Long: `Track a collection, due right away, taking it off the dead-letter list.
+
+With --requeue, a dead-lettered collection is tracked again with the refresh
+policy and paused state it died with, and the policy flags are ignored.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if requeue, _ := cmd.Flags().GetBool("requeue"); requeue {
+			s, err := openStore()
+			if err != nil {
+				return err
+			}
+			defer s.Close()
+
+			entry, err := collection.RequeueAsset(s, args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "requeued %s, %s every %s\n", entry.Collection, entry.Policy.Class, entry.Policy.Interval)
+			return nil
+		}
+
+		chainId, _, err := collection.ParseAssetId(args[0])
+		if err != nil {
+			return err
+		}
+		if _, ok := conf.Chains[chainId]; !ok {
+			return fmt.Errorf("chain %d: %w", chainId, collection.ErrChainNotConfigured)
+		}
+
+		var tracked config.Collection
+		tracked.Class, _ = cmd.Flags().GetString("class")
+		tracked.Interval, _ = cmd.Flags().GetDuration("interval")
+		tracked.MaxStaleness, _ = cmd.Flags().GetDuration("max-staleness")
+
+		policy, err := tracked.Policy()
+		if err != nil {
+			return err
+		}
+
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+
+		entry, err := collection.TrackAsset(s, args[0], policy)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "tracking %s, %s every %s\n", entry.Collection, policy.Class, policy.Interval)
+		return nil
+	},
+}
+
+var queueRemove = &cobra.Command{
+	Use:   "remove <address>",
+	Short: "Stop tracking a collection",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+
+		return collection.UntrackAsset(s, args[0])
+	},
+}
+
+func init() {
+	queueList.Flags().Bool("dead", false, "List the dead-lettered collections instead")
+
+	queueAdd.Flags().String("class", collection.ClassNormal, "Refresh class: hot, normal or archival")
+	queueAdd.Flags().Duration("interval", 0, "Refresh interval, defaults to the class one")
+	queueAdd.Flags().Duration("max-staleness", 0, "Max staleness, defaults to the class one")
+	queueAdd.Flags().Bool("requeue", false, "Requeue a dead-lettered collection with its former policy")
+
+	queue.AddCommand(queueList, queueAdd, queueRemove)
+}
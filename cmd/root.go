@@ -2,25 +2,63 @@ package cmd
 
 import (
 	"fmt"
+	badger "github.com/dgraph-io/badger/v3"
+	"github.com/levelabs/level-go/collection"
+	"github.com/levelabs/level-go/config"
+	"github.com/levelabs/level-go/store"
 	"github.com/spf13/cobra"
 	"os"
 )
 
-var app = &cobra.Command{
+// conf is loaded before any command runs.
+var conf *config.Config
+
+var root = &cobra.Command{
 	Use:   "level-go",
-	Short: "level is something alright",
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("Hello CLI")
+	Short: "level indexes the metadata and traits of NFT collections",
+	// errors are printed once by Execute, without the usage
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		loaded, err := config.Load(cmd.Flags())
+		if err != nil {
+			return err
+		}
+		// the other commands only read or write the store
+		if cmd == serve || cmd == index {
+			if err := loaded.Validate(); err != nil {
+				return err
+			}
+		}
+		conf = loaded
+		return nil
 	},
 }
 
 func Execute() {
-	if err := app.Execute(); err != nil {
+	if err := root.Execute(); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
 }
 
 func init() {
-	app.PersistentFlags().StringP("something", "s", "SOMETHING", "Something about something else")
+	config.Flags(root.PersistentFlags())
+	root.AddCommand(serve, index, traits, queue)
+}
+
+// openStore opens the configured store, without badger's logs cluttering the
+// output. Badger locks its directory, so the commands reading the store can't
+// run alongside serve.
+func openStore() (*store.Store, error) {
+	db, err := badger.Open(badger.DefaultOptions(conf.Storage.Path).WithLogger(nil))
+	if err != nil {
+		return nil, err
+	}
+	s := store.NewStore(db)
+	if err := collection.MigrateLegacyAssets(s); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
 }
@@ -0,0 +1,17 @@
+package cmd
+
+import (
+	"github.com/levelabs/level-go/app"
+	"github.com/spf13/cobra"
+)
+
+var serve = &cobra.Command{
+	Use:   "serve",
+	Short: "Run the sequencing workers, the ownership indexing and the API",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		a := app.NewApp(conf)
+		a.Schedule()
+		return a.Serve()
+	},
+}
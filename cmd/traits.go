@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/levelabs/level-go/collection"
+	"github.com/spf13/cobra"
+	"io"
+	"sort"
+	"text/tabwriter"
+)
+
+var (
+	errTraitsMissing = errors.New("Collection hasn't been sequenced yet")
+)
+
+var traits = &cobra.Command{
+	Use:   "traits <address>",
+	Short: "Print the stored trait distribution of a collection",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := collection.NormalizeAssetId(args[0])
+		if err != nil {
+			return err
+		}
+
+		s, err := openStore()
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+
+		asset, err := collection.LoadAsset(s, id)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), asset)
+		return printTraits(cmd.OutOrStdout(), asset.Trait())
+	},
+}
+
+// printTraits writes every trait value with its count and frequency, most
+// common values first.
+func printTraits(out io.Writer, trait *collection.Trait) error {
+	if trait == nil {
+		return errTraitsMissing
+	}
+	fmt.Fprintf(out, "tokens %d/%d, coverage %.2f\n", trait.Index, trait.Total, trait.Coverage())
+
+	categories := make([]string, 0, len(trait.Counter))
+	for category := range trait.Counter {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TRAIT\tVALUE\tCOUNT\tFREQUENCY")
+	for _, category := range categories {
+		values := trait.Values(category)
+
+		names := make([]string, 0, len(values))
+		for value := range values {
+			names = append(names, value)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if values[names[i]] != values[names[j]] {
+				return values[names[i]] > values[names[j]]
+			}
+			return names[i] < names[j]
+		})
+
+		for _, value := range names {
+			fmt.Fprintf(w, "%s\t%s\t%g\t%.4f\n", category, value, values[value], trait.Frequency(category, value))
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	return printNumericTraits(out, trait)
+}
+
+// printNumericTraits writes the range and histogram of every numeric trait.
+func printNumericTraits(out io.Writer, trait *collection.Trait) error {
+	if len(trait.Numeric) == 0 {
+		return nil
+	}
+
+	categories := make([]string, 0, len(trait.Numeric))
+	for category := range trait.Numeric {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "\nTRAIT\tRANGE\tCOUNT\tMEAN")
+	for _, category := range categories {
+		numeric := trait.Numeric[category]
+		fmt.Fprintf(w, "%s\t%g - %g\t%g\t%.2f\n", category, numeric.Min, numeric.Max, numeric.Count, numeric.Mean())
+		for _, bucket := range numeric.Histogram(collection.HistogramBuckets) {
+			fmt.Fprintf(w, "\t%g - %g\t%g\t\n", bucket.Low, bucket.High, bucket.Count)
+		}
+	}
+	return w.Flush()
+}
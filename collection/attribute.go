@@ -0,0 +1,118 @@
+package collection
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// Kinds of attribute values, after the JSON type they were read from.
+const (
+	ValueString = 0
+	ValueNumber = 1
+	ValueBool   = 2
+)
+
+// OpenSea display types. The numeric ones make a trait aggregate as a range
+// of values rather than one bucket per value; dates are unix timestamps.
+const (
+	DisplayNumber          = "number"
+	DisplayBoostNumber     = "boost_number"
+	DisplayBoostPercentage = "boost_percentage"
+	DisplayDate            = "date"
+)
+
+var numericDisplayTypes = map[string]bool{
+	DisplayNumber:          true,
+	DisplayBoostNumber:     true,
+	DisplayBoostPercentage: true,
+	DisplayDate:            true,
+}
+
+// Attribute is one trait of a token, in the OpenSea metadata format.
+type Attribute struct {
+	Trait       string          `json:"trait_type"`
+	Value       AttributeValue  `json:"value"`
+	DisplayType string          `json:"display_type,omitempty"`
+	MaxValue    *AttributeValue `json:"max_value,omitempty"`
+}
+
+// AttributeValue is a string, number or boolean trait value, written back
+// with the JSON type it was read with.
+type AttributeValue struct {
+	Kind   int
+	Text   string
+	Number float64
+}
+
+func StringValue(text string) AttributeValue {
+	return AttributeValue{Kind: ValueString, Text: text}
+}
+
+func NumberValue(number float64) AttributeValue {
+	return AttributeValue{Kind: ValueNumber, Number: number}
+}
+
+// String is the value as counted by categorical traits.
+func (v AttributeValue) String() string {
+	if v.Kind == ValueNumber {
+		return strconv.FormatFloat(v.Number, 'f', -1, 64)
+	}
+	return v.Text
+}
+
+// Float reads the value as a number, parsing strings such as "7".
+func (v AttributeValue) Float() (float64, bool) {
+	switch v.Kind {
+	case ValueNumber:
+		return v.Number, true
+	case ValueString:
+		number, err := strconv.ParseFloat(v.Text, 64)
+		return number, err == nil
+	}
+	return 0, false
+}
+
+func (v AttributeValue) MarshalJSON() ([]byte, error) {
+	switch v.Kind {
+	case ValueNumber:
+		return json.Marshal(v.Number)
+	case ValueBool:
+		return json.Marshal(v.Text == "true")
+	}
+	return json.Marshal(v.Text)
+}
+
+func (v *AttributeValue) UnmarshalJSON(data []byte) error {
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	switch value := decoded.(type) {
+	case float64:
+		*v = NumberValue(value)
+	case bool:
+		*v = AttributeValue{Kind: ValueBool, Text: strconv.FormatBool(value)}
+	case string:
+		*v = StringValue(value)
+	case nil:
+		*v = StringValue("")
+	default:
+		// objects and arrays aren't trait values, they are kept as text
+		*v = StringValue(string(data))
+	}
+	return nil
+}
+
+// Numeric reads the attribute as a number when it is one: either its
+// display type is numeric and its value parses, or it was written as a JSON
+// number.
+func (a Attribute) Numeric() (float64, bool) {
+	if numericDisplayTypes[a.DisplayType] {
+		return a.Value.Float()
+	}
+	if a.DisplayType == "" && a.Value.Kind == ValueNumber {
+		return a.Value.Number, true
+	}
+	return 0, false
+}
@@ -0,0 +1,73 @@
+package collection
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAttributeValueJSON(t *testing.T) {
+	tests := []struct {
+		raw     string
+		value   AttributeValue
+		encoded string
+	}{
+		{`"Gold"`, StringValue("Gold"), `"Gold"`},
+		{`7`, NumberValue(7), `7`},
+		{`2.5`, NumberValue(2.5), `2.5`},
+		{`true`, AttributeValue{Kind: ValueBool, Text: "true"}, `true`},
+		{`false`, AttributeValue{Kind: ValueBool, Text: "false"}, `false`},
+		{`null`, StringValue(""), `""`},
+		{`{"a":1}`, StringValue(`{"a":1}`), `"{\"a\":1}"`},
+		{`[1,2]`, StringValue(`[1,2]`), `"[1,2]"`},
+	}
+	for _, test := range tests {
+		var value AttributeValue
+		if err := json.Unmarshal([]byte(test.raw), &value); err != nil {
+			t.Errorf("Unmarshal(%s) = %v", test.raw, err)
+			continue
+		}
+		if value != test.value {
+			t.Errorf("Unmarshal(%s) = %+v, want %+v", test.raw, value, test.value)
+		}
+
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			t.Errorf("Marshal(%+v) = %v", value, err)
+			continue
+		}
+		if string(encoded) != test.encoded {
+			t.Errorf("Marshal(%+v) = %s, want %s", value, encoded, test.encoded)
+		}
+	}
+
+	if err := json.Unmarshal([]byte(`"unterminated`), new(AttributeValue)); err == nil {
+		t.Error("Unmarshal of malformed JSON succeeded")
+	}
+}
+
+func TestAttributeNumeric(t *testing.T) {
+	tests := []struct {
+		name      string
+		attribute Attribute
+		number    float64
+		ok        bool
+	}{
+		{"json number", Attribute{Value: NumberValue(3)}, 3, true},
+		{"numeric display of a string", Attribute{Value: StringValue("7"), DisplayType: DisplayBoostNumber}, 7, true},
+		{"date", Attribute{Value: NumberValue(1546360800), DisplayType: DisplayDate}, 1546360800, true},
+		{"numeric display of text", Attribute{Value: StringValue("seven"), DisplayType: DisplayNumber}, 0, false},
+		{"string of digits", Attribute{Value: StringValue("7")}, 0, false},
+		{"boolean", Attribute{Value: AttributeValue{Kind: ValueBool, Text: "true"}}, 0, false},
+		{"other display of a number", Attribute{Value: NumberValue(3), DisplayType: "ranking"}, 0, false},
+	}
+	for _, test := range tests {
+		number, ok := test.attribute.Numeric()
+		if ok != test.ok || number != test.number {
+			t.Errorf("%s: Numeric = %v %v, want %v %v", test.name, number, ok, test.number, test.ok)
+		}
+	}
+
+	if text := NumberValue(2.50).String(); text != "2.5" {
+		t.Errorf("String of 2.5 = %q", text)
+	}
+}
@@ -0,0 +1,130 @@
+package collection
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+)
+
+var (
+	errContractUnsupported = errors.New("Contract is neither an ERC-721 nor an ERC-1155 collection")
+	errMetadataUnsupported = errors.New("Collection exposes neither a base uri nor token uris")
+	errCapabilityUnknown   = errors.New("Unknown capability name")
+)
+
+// Capabilities is the set of interfaces a collection contract declares
+// through ERC-165.
+type Capabilities uint
+
+const (
+	CapabilityERC721 Capabilities = 1 << iota
+	CapabilityMetadata
+	CapabilityEnumerable
+	CapabilityERC1155
+	CapabilityMetadataURI
+	CapabilityRoyalty
+	CapabilityMetadataUpdate
+)
+
+// interfaceERC165 is the id a contract answers to when it implements
+// supportsInterface at all.
+var interfaceERC165 = [4]byte{0x01, 0xff, 0xc9, 0xa7}
+
+// interfaces maps every capability to its ERC-165 interface id.
+var interfaces = map[Capabilities][4]byte{
+	CapabilityERC721:         {0x80, 0xac, 0x58, 0xcd},
+	CapabilityMetadata:       {0x5b, 0x5e, 0x13, 0x9f},
+	CapabilityEnumerable:     {0x78, 0x0e, 0x9d, 0x63},
+	CapabilityERC1155:        {0xd9, 0xb6, 0x7a, 0x26},
+	CapabilityMetadataURI:    {0x0e, 0x89, 0x34, 0x1c},
+	CapabilityRoyalty:        {0x2a, 0x55, 0x20, 0x5a},
+	CapabilityMetadataUpdate: {0x49, 0x06, 0x49, 0x06},
+}
+
+var capabilityNames = map[Capabilities]string{
+	CapabilityERC721:         "erc721",
+	CapabilityMetadata:       "erc721_metadata",
+	CapabilityEnumerable:     "erc721_enumerable",
+	CapabilityERC1155:        "erc1155",
+	CapabilityMetadataURI:    "erc1155_metadata_uri",
+	CapabilityRoyalty:        "erc2981",
+	CapabilityMetadataUpdate: "erc4906",
+}
+
+func (c Capabilities) Has(capability Capabilities) bool {
+	return c&capability == capability
+}
+
+// Names lists the capabilities in the set by name, sorted.
+func (c Capabilities) Names() []string {
+	names := []string{}
+	for capability, name := range capabilityNames {
+		if c.Has(capability) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c Capabilities) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.Names())
+}
+
+func (c *Capabilities) UnmarshalJSON(data []byte) error {
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return err
+	}
+
+	*c = 0
+	for _, name := range names {
+		found := false
+		for capability, known := range capabilityNames {
+			if known == name {
+				*c |= capability
+				found = true
+			}
+		}
+		if !found {
+			return errCapabilityUnknown
+		}
+	}
+	return nil
+}
+
+// ProbeCapabilities asks the contract which interfaces it supports. The
+// second result is false when the contract doesn't implement ERC-165, that
+// is it answers false or reverts, in which case nothing is known about it.
+// Any other failure is returned, so the probe can run again.
+func ProbeCapabilities(collection *Collection) (Capabilities, bool, error) {
+	opts := &bind.CallOpts{}
+
+	ok, err := collection.SupportsInterface(opts, interfaceERC165)
+	if errors.Is(err, bind.ErrNoCode) {
+		return 0, false, errContractNotDeployed
+	}
+	if callDeclined(err) || (err == nil && !ok) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	var capabilities Capabilities
+	for capability, id := range interfaces {
+		ok, err := collection.SupportsInterface(opts, id)
+		if callDeclined(err) {
+			continue
+		}
+		if err != nil {
+			return 0, false, err
+		}
+		if ok {
+			capabilities |= capability
+		}
+	}
+	return capabilities, true, nil
+}
@@ -0,0 +1,130 @@
+package collection
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+var errTestTransport = errors.New("dial tcp: connection refused")
+
+// testBackend answers contract calls through call, keyed by the 4 byte
+// selector and first argument, and reports code at every address unless
+// noCode is set. Any other backend method panics.
+type testBackend struct {
+	bind.ContractBackend
+	noCode bool
+	call   func(data []byte) ([]byte, error)
+}
+
+func (b *testBackend) CodeAt(ctx context.Context, contract ethcommon.Address, blockNumber *big.Int) ([]byte, error) {
+	if b.noCode {
+		return nil, nil
+	}
+	return []byte{0x60}, nil
+}
+
+func (b *testBackend) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return b.call(call.Data)
+}
+
+func abiBool(value bool) []byte {
+	word := make([]byte, 32)
+	if value {
+		word[31] = 1
+	}
+	return word
+}
+
+// supporting answers supportsInterface with the given interface ids.
+func supporting(ids ...[4]byte) func(data []byte) ([]byte, error) {
+	return func(data []byte) ([]byte, error) {
+		var id [4]byte
+		copy(id[:], data[4:8])
+		for _, supported := range ids {
+			if supported == id {
+				return abiBool(true), nil
+			}
+		}
+		return abiBool(false), nil
+	}
+}
+
+func TestProbeCapabilities(t *testing.T) {
+	tests := []struct {
+		name         string
+		backend      *testBackend
+		capabilities Capabilities
+		ok           bool
+		err          error
+	}{
+		{
+			"erc721 with metadata",
+			&testBackend{call: supporting(interfaceERC165, interfaces[CapabilityERC721], interfaces[CapabilityMetadata])},
+			CapabilityERC721 | CapabilityMetadata, true, nil,
+		},
+		{
+			"erc1155",
+			&testBackend{call: supporting(interfaceERC165, interfaces[CapabilityERC1155])},
+			CapabilityERC1155, true, nil,
+		},
+		{
+			"no erc165",
+			&testBackend{call: supporting()},
+			0, false, nil,
+		},
+		{
+			"reverting erc165",
+			&testBackend{call: func([]byte) ([]byte, error) { return nil, errors.New("execution reverted") }},
+			0, false, nil,
+		},
+		{
+			"empty answer",
+			&testBackend{call: func([]byte) ([]byte, error) { return nil, nil }},
+			0, false, nil,
+		},
+		{
+			"transport failure",
+			&testBackend{call: func([]byte) ([]byte, error) { return nil, errTestTransport }},
+			0, false, errTestTransport,
+		},
+		{
+			"no contract",
+			&testBackend{noCode: true, call: func([]byte) ([]byte, error) { return nil, nil }},
+			0, false, errContractNotDeployed,
+		},
+	}
+
+	for _, test := range tests {
+		collection, err := NewCollection(ethcommon.Address{}, test.backend)
+		if err != nil {
+			t.Fatal(err)
+		}
+		capabilities, ok, err := ProbeCapabilities(collection)
+		if capabilities != test.capabilities || ok != test.ok || !errors.Is(err, test.err) {
+			t.Errorf("%s: ProbeCapabilities = %v %v %v, want %v %v %v",
+				test.name, capabilities.Names(), ok, err, test.capabilities.Names(), test.ok, test.err)
+		}
+	}
+}
+
+func TestProbeFailureKeepsStandardUnknown(t *testing.T) {
+	backend := &testBackend{call: func([]byte) ([]byte, error) { return nil, errTestTransport }}
+	collection, err := NewCollection(ethcommon.Address{}, backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	asset := NewAsset(1, "0x01", 0, 0)
+	if err := asset.probe(collection); err == nil {
+		t.Fatal("probe succeeded over a failed transport")
+	}
+	if asset.Standard() != StandardUnknown {
+		t.Errorf("standard = %d after a failed probe, want unknown", asset.Standard())
+	}
+}
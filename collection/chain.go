@@ -0,0 +1,65 @@
+package collection
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	ErrChainNotConfigured = errors.New("No client is configured for the chain")
+
+	errAssetIdInvalid = errors.New("Asset id should be <address> or <chain id>:<address>")
+)
+
+const (
+	ChainMainnet  uint64 = 1
+	ChainPolygon  uint64 = 137
+	ChainArbitrum uint64 = 42161
+	ChainBase     uint64 = 8453
+)
+
+// ChainConfig holds the endpoints and finality setting of one EVM chain.
+type ChainConfig struct {
+	EthUri        string
+	EthWsUri      string
+	Confirmations uint64
+}
+
+// AssetId identifies a collection across chains as "<chain id>:<address>",
+// with the address in lower case hex.
+func AssetId(chainId uint64, address string) string {
+	return strconv.FormatUint(chainId, 10) + ":" + strings.ToLower(address)
+}
+
+// ParseAssetId reads an asset id. A bare address is taken to be on mainnet.
+func ParseAssetId(id string) (uint64, string, error) {
+	chainId := ChainMainnet
+	address := id
+
+	if i := strings.IndexByte(id, ':'); i >= 0 {
+		parsed, err := strconv.ParseUint(id[:i], 10, 64)
+		if err != nil {
+			return 0, "", errAssetIdInvalid
+		}
+		chainId = parsed
+		address = id[i+1:]
+	}
+
+	if !ethcommon.IsHexAddress(address) {
+		return 0, "", errAssetIdInvalid
+	}
+	return chainId, strings.ToLower(address), nil
+}
+
+// NormalizeAssetId rewrites an asset id, or a bare mainnet address, in its
+// canonical form.
+func NormalizeAssetId(id string) (string, error) {
+	chainId, address, err := ParseAssetId(id)
+	if err != nil {
+		return "", err
+	}
+	return AssetId(chainId, address), nil
+}
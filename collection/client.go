@@ -3,17 +3,34 @@ package collection
 import (
 	"errors"
 	"io"
+	"log"
 	net "net/http"
+	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/ethclient"
 	shell "github.com/ipfs/go-ipfs-api"
+
+	"github.com/levelabs/level-go/common"
 )
 
 var (
 	errClientEthereumFailed = errors.New("failed connection with eth client")
 	errClientIPFSFailed     = errors.New("failed connection with ipfs client")
 
-	errClientIPFSGet = errors.New("There was an issue when querying the IPFS client")
+	errClientIPFSGet    = errors.New("There was an issue when querying the IPFS client")
+	errClientHttpGet    = errors.New("There was an issue when querying the http host")
+	errClientArweaveGet = errors.New("There was an issue when querying the Arweave gateway")
+
+	errArweaveManifestInvalid = errors.New("Transaction is not an Arweave path manifest")
+)
+
+const (
+	arweaveManifestType = "arweave/paths"
+
+	// fetchTimeout bounds a request to a metadata host or the Arweave
+	// gateway, so a host which never answers doesn't hold a crawl worker.
+	fetchTimeout = 30 * time.Second
 )
 
 type ClientFetcher interface {
@@ -21,70 +38,214 @@ type ClientFetcher interface {
 }
 
 type Client struct {
-	Ethereum Ethereum
-	IPFS     IPFS
-	Http     Http
+	Chains  map[uint64]*Ethereum
+	IPFS    IPFS
+	Http    Http
+	Arweave Arweave
+	Data    Data
 }
 
+// IPFS reads files through the API of an IPFS node. Gateways are the base
+// urls of HTTP gateways able to serve the same paths, read through Http
+// when the node is down.
 type IPFS struct {
-	Client *shell.Shell
+	Client   *shell.Shell
+	Gateways []string
+	Http     net.Client
+
+	// warnings is shared by the copies of the client, so a failing node is
+	// reported once per interval rather than once per token.
+	warnings *throttledLog
 }
 
+// Ethereum holds the RPC connection to one EVM chain. Stream is an optional
+// websocket connection used for subscriptions, nil when none is configured
+// or it couldn't be dialed.
 type Ethereum struct {
+	ChainId       uint64
+	Confirmations uint64
+
 	Client *ethclient.Client
+	Stream *ethclient.Client
 }
 
 type Http struct {
 	Client net.Client
 }
 
+// Arweave reads transactions through an HTTP gateway. Gateway is the base
+// url of the gateway, e.g. https://arweave.net, and can point at a local
+// stand-in server.
+type Arweave struct {
+	Client  net.Client
+	Gateway string
+}
+
+// ArweaveManifest is an Arweave path manifest, mapping relative paths to the
+// transaction ids holding their content.
+type ArweaveManifest struct {
+	Manifest string                 `json:"manifest"`
+	Version  string                 `json:"version"`
+	Paths    map[string]ArweavePath `json:"paths"`
+}
+
+type ArweavePath struct {
+	Id string `json:"id"`
+}
+
 type ClientConfig struct {
-	EthUri  string
-	IPFSUri string
+	Chains       map[uint64]ChainConfig
+	IPFSUri      string
+	IPFSGateways []string
+	ArweaveUri   string
 }
 
 func BuildClient(config ClientConfig) (*Client, error) {
 	ipfsUri := config.IPFSUri
-	ethUri := config.EthUri
+
+	gateways := make([]string, len(config.IPFSGateways))
+	for i, gateway := range config.IPFSGateways {
+		gateways[i] = strings.TrimRight(gateway, "/")
+	}
 
 	ipfs := IPFS{
-		Client: shell.NewShell(ipfsUri),
+		Client:   shell.NewShell(ipfsUri),
+		Gateways: gateways,
+		Http:     net.Client{Timeout: ipfsGatewayTimeout},
+		warnings: newThrottledLog(ipfsWarnInterval),
 	}
 
-	eth, err := ethclient.Dial(ethUri)
+	chains := make(map[uint64]*Ethereum, len(config.Chains))
+	for chainId, chain := range config.Chains {
+		ethereum, err := dialChain(chainId, chain)
+		if err != nil {
+			return nil, err
+		}
+		chains[chainId] = ethereum
+	}
+
+	http := Http{
+		Client: net.Client{Timeout: fetchTimeout},
+	}
+
+	arweave := Arweave{
+		Client:  net.Client{Timeout: fetchTimeout},
+		Gateway: strings.TrimRight(config.ArweaveUri, "/"),
+	}
+
+	client := &Client{
+		Chains:  chains,
+		IPFS:    ipfs,
+		Http:    http,
+		Arweave: arweave,
+	}
+
+	return client, nil
+}
+
+func (http Http) Get(uri string) (io.ReadCloser, error) {
+	res, err := http.Client.Get(uri)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != net.StatusOK {
+		res.Body.Close()
+		return nil, errClientHttpGet
+	}
+	return res.Body, nil
+}
+
+func dialChain(chainId uint64, config ChainConfig) (*Ethereum, error) {
+	eth, err := ethclient.Dial(config.EthUri)
 	if err != nil {
 		return nil, errClientEthereumFailed
 	}
 
 	ethereum := Ethereum{
-		Client: eth,
+		ChainId:       chainId,
+		Confirmations: config.Confirmations,
+		Client:        eth,
 	}
 
-	http := Http{
-		Client: net.Client{},
+	if config.EthWsUri != "" {
+		stream, err := ethclient.Dial(config.EthWsUri)
+		if err != nil {
+			log.Printf("[WARN]: Streaming unavailable on chain %d, falling back to polling %s", chainId, err)
+		} else {
+			ethereum.Stream = stream
+		}
 	}
+	return &ethereum, nil
+}
 
-	client := &Client{
-		Ethereum: ethereum,
-		IPFS:     ipfs,
-		Http:     http,
+// Chain returns the connection to a chain.
+func (client *Client) Chain(chainId uint64) (*Ethereum, error) {
+	ethereum, ok := client.Chains[chainId]
+	if !ok {
+		return nil, ErrChainNotConfigured
 	}
+	return ethereum, nil
+}
 
-	return client, nil
+// Route picks the fetcher able to read a token uri, along with the location
+// to hand to its Get.
+func (client *Client) Route(uri string) (ClientFetcher, string, error) {
+	u, err := ParseUri(uri)
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch u.Scheme {
+	case UriIPFS:
+		return client.IPFS, joinPath(u.Host, u.Path), nil
+	case UriArweave:
+		return client.Arweave, joinPath(u.Host, u.Path), nil
+	case UriHttp:
+		return client.Http, uri, nil
+	case UriData:
+		return client.Data, uri, nil
+	}
+	return nil, "", errURIFormatNotFound
+}
+
+func joinPath(host string, path string) string {
+	if path == "" {
+		return host
+	}
+	return host + "/" + path
 }
 
-func (ipfs IPFS) Get(uri string) (io.ReadCloser, error) {
-	res, err := ipfs.Client.Cat(uri)
+// Get fetches a transaction, or a path within a manifest, from the gateway.
+// The uri may be given as "ar://<txid>/<path>" or "<txid>/<path>".
+func (arweave Arweave) Get(uri string) (io.ReadCloser, error) {
+	return arweave.get(strings.TrimPrefix(uri, "ar://"))
+}
+
+// Manifest fetches the raw, unresolved path manifest stored at txid.
+func (arweave Arweave) Manifest(txid string) (*ArweaveManifest, error) {
+	res, err := arweave.get("raw/" + txid)
 	if err != nil {
-		return nil, errClientIPFSGet
+		return nil, err
 	}
-	return res, nil
+
+	var manifest ArweaveManifest
+	if err := common.UnmarshalJSON(res, &manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Manifest != arweaveManifestType {
+		return nil, errArweaveManifestInvalid
+	}
+	return &manifest, nil
 }
 
-func (http Http) Get(uri string) (io.ReadCloser, error) {
-	res, err := http.Client.Get(uri)
+func (arweave Arweave) get(path string) (io.ReadCloser, error) {
+	res, err := arweave.Client.Get(arweave.Gateway + "/" + path)
 	if err != nil {
 		return nil, err
 	}
+	if res.StatusCode != net.StatusOK {
+		res.Body.Close()
+		return nil, errClientArweaveGet
+	}
 	return res.Body, nil
 }
@@ -0,0 +1,117 @@
+package collection
+
+import (
+	"fmt"
+	"io/ioutil"
+	net "net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+)
+
+// testManifest lays out token files under tokens/, next to files and
+// folders which aren't tokens.
+const testManifest = `{
+	"manifest": "arweave/paths",
+	"version": "0.1.0",
+	"paths": {
+		"tokens/1": {"id": "tx-1"},
+		"tokens/2.json": {"id": "tx-2"},
+		"tokens/3": {"id": "tx-3"},
+		"tokens/collection.json": {"id": "tx-collection"},
+		"tokens/index.html": {"id": "tx-index"},
+		"tokens/images/1.png": {"id": "tx-image"},
+		"other/4": {"id": "tx-4"}
+	}
+}`
+
+// newTestGateway serves the manifest at raw/manifest, a plain transaction
+// at raw/plain and every token file by its transaction id. Files which
+// aren't tokens answer metadata that fails the test when read.
+func newTestGateway(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(net.HandlerFunc(func(w net.ResponseWriter, r *net.Request) {
+		switch r.URL.Path {
+		case "/raw/manifest":
+			fmt.Fprint(w, testManifest)
+		case "/raw/plain":
+			fmt.Fprint(w, `{"name":"not a manifest"}`)
+		case "/tx-1", "/tx-2", "/tx-3":
+			fmt.Fprintf(w, `{"name":"%s","attributes":[{"trait_type":"Fur","value":"Gold"}]}`, r.URL.Path[1:])
+		case "/tx-collection", "/tx-index", "/tx-image", "/tx-4":
+			t.Errorf("read %s, which isn't a token under tokens/", r.URL.Path)
+			fmt.Fprint(w, `{}`)
+		default:
+			net.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestArweaveManifest(t *testing.T) {
+	gateway := newTestGateway(t)
+	arweave := Arweave{Client: net.Client{}, Gateway: gateway.URL}
+
+	manifest, err := arweave.Manifest("manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Paths) != 7 || manifest.Paths["tokens/2.json"].Id != "tx-2" {
+		t.Errorf("manifest paths = %+v", manifest.Paths)
+	}
+
+	if _, err := arweave.Manifest("plain"); err != errArweaveManifestInvalid {
+		t.Errorf("Manifest of a plain transaction = %v, want %v", err, errArweaveManifestInvalid)
+	}
+	if _, err := arweave.Manifest("missing"); err != errClientArweaveGet {
+		t.Errorf("Manifest of a missing transaction = %v, want %v", err, errClientArweaveGet)
+	}
+
+	res, err := arweave.Get("ar://tx-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Close()
+	body, _ := ioutil.ReadAll(res)
+	if len(body) == 0 {
+		t.Error("Get of ar://tx-1 read nothing")
+	}
+}
+
+func TestRunArweaveTraitGetter(t *testing.T) {
+	gateway := newTestGateway(t)
+	manager := Manager{
+		Connection: &Client{Arweave: Arweave{Client: net.Client{}, Gateway: gateway.URL}},
+		Crawl:      DefaultCrawlConfig(),
+	}
+
+	asset := NewAsset(1, "0x0000000000000000000000000000000000000001", 0, 0)
+	asset.uri = &Uri{Scheme: UriArweave, Host: "manifest", Path: "tokens/"}
+
+	trait := NewTrait()
+	if err := manager.RunArweaveTraitGetter(trait, asset); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := make([]string, len(asset.tokens))
+	for i, token := range asset.tokens {
+		ids[i] = token.Id
+		if token.Name != "tx-"+token.Id {
+			t.Errorf("token %s read from %s", token.Id, token.Name)
+		}
+	}
+	sort.Strings(ids)
+	if fmt.Sprint(ids) != "[1 2 3]" {
+		t.Errorf("token ids = %v, want [1 2 3]", ids)
+	}
+	if trait.Total != 3 || trait.Count("Fur", "Gold") != 3 {
+		t.Errorf("trait total %d, Fur Gold %g, want 3 and 3", trait.Total, trait.Count("Fur", "Gold"))
+	}
+}
+
+func TestTokenPaths(t *testing.T) {
+	ids, paths := tokenPaths([]string{"index.html", "10.json", "2", "2.json", "007", "-1", "+3", "images", "a1"})
+	if fmt.Sprint(ids) != "[7 10 2]" || fmt.Sprint(paths) != "[007 10.json 2]" {
+		t.Errorf("tokenPaths = %v %v, want [7 10 2] [007 10.json 2]", ids, paths)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"math/big"
 	"net/url"
+	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	ethcommon "github.com/ethereum/go-ethereum/common"
@@ -17,35 +19,88 @@ var (
 	errFailedConversionToBytes      = errors.New("Failed to convert asset to byte[]")
 	errURIFormatNotFound            = errors.New("An unknown URI format has been found")
 	errCreatingCollectionEthBinding = errors.New("There was an issue creating the eth binding ")
-	errBaseUriNotExist              = errors.New("Couldn't find the base uri")
 )
 
 const (
 	UriIPFS    = 1
 	UriHttp    = 2
 	UriArweave = 3
+	UriToken   = 4
+	UriData    = 5
 )
 
+// Token standards of a collection. An asset starts out unknown until its
+// contract is probed.
+const (
+	StandardUnknown = 0
+	StandardERC721  = 1
+	StandardERC1155 = 2
+)
+
+var arweaveGateways = map[string]bool{
+	"arweave.net":     true,
+	"www.arweave.net": true,
+	"ar-io.net":       true,
+}
+
+// Uri locates the metadata of a collection. For IPFS and Arweave, Host holds
+// the root cid or manifest transaction id and Path the prefix of token paths
+// within it. UriToken marks a collection without a base uri, whose tokens are
+// resolved one at a time through tokenURI. UriData marks a uri embedding its
+// own content.
 type Uri struct {
-	Scheme int
-	Host   string
+	Scheme int    `json:"scheme"`
+	Host   string `json:"host"`
+	Path   string `json:"path,omitempty"`
 }
 
-// baseURI     *string           `json:"baseURI"`
 type Asset struct {
-	address     ethcommon.Address `json:"address"`
-	uri         *Uri              `json:"uri"`
-	totalSupply big.Int           `json:"tokenSupply"`
+	chainId      uint64
+	address      ethcommon.Address
+	standard     int
+	capabilities Capabilities
+	uri          *Uri
+	totalSupply  big.Int
+
+	trait  *Trait
+	tokens []*Token
 
-	trait *Trait
+	tokenRange *TokenRange
 
 	priority int64
 	index    int
+
+	policy      RefreshPolicy
+	paused      bool
+	lastSuccess time.Time
+
+	// attempts counts the failed runs since the last successful one
+	attempts     int
+	lastRun      time.Time
+	lastDuration time.Duration
+	lastTokens   int
+	lastResult   string
+	lastError    string
 }
 
-func NewAsset(address string, priority int64, index int) *Asset {
+// assetJSON is the encoding of an Asset, at AssetSchemaVersion.
+type assetJSON struct {
+	Version      int          `json:"version"`
+	ChainId      uint64       `json:"chain_id"`
+	Address      string       `json:"address"`
+	Standard     int          `json:"standard,omitempty"`
+	Capabilities Capabilities `json:"capabilities,omitempty"`
+	Uri          *Uri         `json:"uri,omitempty"`
+	TotalSupply  string       `json:"total_supply"`
+	TokenRange   *TokenRange  `json:"token_range,omitempty"`
+	Trait        *Trait       `json:"trait,omitempty"`
+}
+
+func NewAsset(chainId uint64, address string, priority int64, index int) *Asset {
 	a := Asset{
+		chainId:  chainId,
 		address:  ethcommon.HexToAddress(address),
+		policy:   DefaultRefreshPolicy(),
 		priority: priority,
 		index:    index,
 	}
@@ -56,6 +111,76 @@ func (a *Asset) SetTotalSupply(totalSupply big.Int) {
 	a.totalSupply = totalSupply
 }
 
+func (a *Asset) SetStandard(standard int) {
+	a.standard = standard
+}
+
+func (a *Asset) Standard() int {
+	return a.standard
+}
+
+func (a *Asset) Policy() RefreshPolicy {
+	return a.policy
+}
+
+// SetPolicy changes how often the asset is refreshed, from its next run on.
+func (a *Asset) SetPolicy(policy RefreshPolicy) {
+	a.policy = policy
+}
+
+func (a *Asset) Capabilities() Capabilities {
+	return a.capabilities
+}
+
+// Probe classifies the contract through ERC-165, keeping its capabilities
+// and deriving its standard from them. Contracts predating ERC-165 are taken
+// to be ERC-721 collections of unknown capabilities.
+func (a *Asset) Probe(ethereum *Ethereum) error {
+	collection, err := NewCollection(a.address, ethereum.Client)
+	if err != nil {
+		return errCreatingCollectionEthBinding
+	}
+	return a.probe(collection)
+}
+
+// probe sets the capabilities and standard of the asset, leaving them
+// unknown when the contract couldn't be asked.
+func (a *Asset) probe(collection *Collection) error {
+	capabilities, ok, err := ProbeCapabilities(collection)
+	if err != nil {
+		return err
+	}
+	if ok && !capabilities.Has(CapabilityERC721) && !capabilities.Has(CapabilityERC1155) {
+		return errContractUnsupported
+	}
+
+	a.capabilities = capabilities
+	a.standard = StandardERC721
+	if capabilities.Has(CapabilityERC1155) {
+		a.standard = StandardERC1155
+	}
+	return nil
+}
+
+// SetTokenRange sets the known token ids of a collection which doesn't
+// implement ERC721Enumerable, with end exclusive.
+func (a *Asset) SetTokenRange(start int64, end int64) {
+	a.tokenRange = &TokenRange{Start: start, End: end}
+}
+
+func (a *Asset) Trait() *Trait {
+	return a.trait
+}
+
+// Id identifies the asset across chains, see AssetId.
+func (a *Asset) Id() string {
+	return AssetId(a.chainId, a.Address())
+}
+
+func (a *Asset) ChainId() uint64 {
+	return a.chainId
+}
+
 func (a *Asset) Address() string {
 	return a.address.String()
 }
@@ -65,50 +190,158 @@ func (a *Asset) AddressBytes() []byte {
 }
 
 func (a *Asset) String() string {
-	return fmt.Sprintf("%s - %s", a.address, (a.totalSupply).String())
+	return fmt.Sprintf("%s - %s", a.Id(), (a.totalSupply).String())
+}
+
+func (a *Asset) Uri() *Uri {
+	return a.uri
+}
+
+func (a *Asset) TotalSupply() *big.Int {
+	return new(big.Int).Set(&a.totalSupply)
 }
 
 func (a *Asset) MarshalJSON() ([]byte, error) {
-	bytes, err := json.Marshal(a.String())
+	encoded := assetJSON{
+		Version:      AssetSchemaVersion,
+		ChainId:      a.chainId,
+		Address:      a.Address(),
+		Standard:     a.standard,
+		Capabilities: a.capabilities,
+		Uri:          a.uri,
+		TotalSupply:  a.totalSupply.String(),
+		TokenRange:   a.tokenRange,
+		Trait:        a.trait,
+	}
+
+	bytes, err := json.Marshal(&encoded)
 	if err != nil {
 		return nil, errFailedConversionToBytes
 	}
 	return bytes, nil
 }
 
+// UnmarshalJSON reads an asset written by any schema version, migrating it
+// to the current one.
+func (a *Asset) UnmarshalJSON(data []byte) error {
+	fields, err := decodeAssetFields(data)
+	if err != nil {
+		return err
+	}
+
+	migrated, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+
+	var decoded assetJSON
+	if err := json.Unmarshal(migrated, &decoded); err != nil {
+		return err
+	}
+
+	a.chainId = decoded.ChainId
+	a.address = ethcommon.HexToAddress(decoded.Address)
+	a.standard = decoded.Standard
+	a.capabilities = decoded.Capabilities
+	a.uri = decoded.Uri
+	a.tokenRange = decoded.TokenRange
+	a.trait = decoded.Trait
+	if _, ok := a.totalSupply.SetString(decoded.TotalSupply, 10); !ok {
+		a.totalSupply.SetInt64(0)
+	}
+	return nil
+}
+
 func (a *Asset) SetBaseUri(ethereum *Ethereum) error {
 	collection, err := NewCollection(a.address, ethereum.Client)
 	if err != nil {
 		return errCreatingCollectionEthBinding
 	}
+	return a.setBaseUri(collection)
+}
 
+// setBaseUri reads the base uri of the collection. Only a contract without
+// one, reverting or answering an empty uri, falls back on tokenURI; failing
+// to reach the contract is returned, for the run to be retried.
+func (a *Asset) setBaseUri(collection *Collection) error {
+	// on-chain collections inline each token, if they have a base uri at all
 	uri, err := collection.BaseURI(&bind.CallOpts{})
-	if err != nil {
-		return errBaseUriNotExist
+	if errors.Is(err, bind.ErrNoCode) {
+		return errContractNotDeployed
 	}
-
-	base, err := url.Parse(uri)
-	if err != nil {
+	if err != nil && !callDeclined(err) {
 		return err
 	}
+	if err != nil || uri == "" || IsDataUri(uri) {
+		// without a base uri every token is resolved through tokenURI
+		if a.uri == nil {
+			a.uri = &Uri{Scheme: UriToken}
+		}
+		return nil
+	}
 
 	if a.uri == nil {
-		var u Uri
-		switch base.Scheme {
-		case "ipfs":
-			u.Scheme = UriIPFS
-			u.Host = base.Host
-		case "https":
-			u.Scheme = UriHttp
-			u.Host = common.TrimRightNumber(uri)
-		default:
-			return errURIFormatNotFound
+		u, err := ParseUri(uri)
+		if err != nil {
+			return err
 		}
-		a.uri = &u
+		a.uri = u
 	}
 	return nil
 }
 
+// ParseUri classifies a base uri by the client able to fetch it.
+func ParseUri(uri string) (*Uri, error) {
+	if IsDataUri(uri) {
+		return &Uri{Scheme: UriData}, nil
+	}
+
+	base, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	var u Uri
+	switch base.Scheme {
+	case "ipfs":
+		return ParseIPFSPath(uri)
+	case "ar":
+		u.Scheme = UriArweave
+		u.Host = base.Host
+		u.Path = strings.TrimPrefix(base.Path, "/")
+	case "http", "https":
+		if arweaveGateways[base.Host] {
+			txid, path := splitArweavePath(base.Path)
+			u.Scheme = UriArweave
+			u.Host = txid
+			u.Path = path
+			break
+		}
+		if ipfs, ok := parseIPFSGateway(base); ok {
+			return ipfs, nil
+		}
+		u.Scheme = UriHttp
+		u.Host = common.TrimRightNumber(uri)
+	case "":
+		// a bare cid or /ipfs/ path
+		if ipfs, err := ParseIPFSPath(uri); err == nil {
+			return ipfs, nil
+		}
+		return nil, errURIFormatNotFound
+	default:
+		return nil, errURIFormatNotFound
+	}
+	return &u, nil
+}
+
+func splitArweavePath(path string) (string, string) {
+	parts := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)
+	if len(parts) < 2 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
 // func (a *Asset) RandomTokenBaseUri(collection *Collection) (*string, error) {
 // 	tokenIndex := big.NewInt(0) // using index 0
 //
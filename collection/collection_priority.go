@@ -2,6 +2,8 @@ package collection
 
 import (
 	"container/heap"
+	"log"
+	"sync"
 	"time"
 )
 
@@ -37,29 +39,229 @@ func (cq PriorityQueue) Swap(i, j int) {
 	cq[j].index = j
 }
 
-// update modifies the priority and value of an Asset in the PriorityQueue.
-func (cq *PriorityQueue) update(asset *Asset, priority int64) {
+// Update modifies the priority and value of an Asset in the PriorityQueue.
+func (cq *PriorityQueue) Update(asset *Asset, priority int64) {
 	asset.priority = priority
 	heap.Fix(cq, asset.index)
 }
 
+// Remove takes an Asset out of the PriorityQueue, wherever it stands.
+func (cq *PriorityQueue) Remove(asset *Asset) {
+	heap.Remove(cq, asset.index)
+}
+
+// NewPriorityQueue builds the waitlist from assets keyed by asset id.
 func NewPriorityQueue(assets map[string]int64) *PriorityQueue {
-	cq := make(PriorityQueue, len(assets))
-	i := 0
-	for address, priority := range assets {
-		cq[i] = NewAsset(address, priority, i)
-		i++
+	cq := make(PriorityQueue, 0, len(assets))
+	for id, priority := range assets {
+		chainId, address, err := ParseAssetId(id)
+		if err != nil {
+			log.Printf("[WARN]: Skipping asset %s %s", id, err)
+			continue
+		}
+		cq = append(cq, NewAsset(chainId, address, priority, len(cq)))
 	}
 	heap.Init(&cq)
 	return &cq
 }
 
 func (pq *PriorityQueue) PriorityQueuePush(asset *Asset) {
+	pq.PriorityQueuePushAt(asset, time.Now())
+}
+
+// PriorityQueuePushAt queues the asset to run no earlier than notBefore.
+func (pq *PriorityQueue) PriorityQueuePushAt(asset *Asset, notBefore time.Time) {
 	heap.Push(pq, asset)
-	pq.update(asset, time.Now().UnixNano())
+	pq.Update(asset, notBefore.UnixNano())
+}
+
+// Peek returns the asset due first without removing it.
+func (pq PriorityQueue) Peek() *Asset {
+	return pq[0]
 }
 
 func (pq *PriorityQueue) PriorityQueueRemove() (*Asset, error) {
 	asset := heap.Pop(pq).(*Asset)
 	return asset, nil
 }
+
+// Waitlist guards a PriorityQueue for concurrent workers. A collection taken
+// off the queue is leased to its worker until released, and can't be queued
+// again meanwhile, so no collection is sequenced twice at once. A leased
+// collection removed from the waitlist is dropped once its lease ends.
+type Waitlist struct {
+	mu      sync.Mutex
+	queue   *PriorityQueue
+	queued  map[string]*Asset
+	leased  map[string]bool
+	dropped map[string]bool
+}
+
+func NewWaitlist(queue *PriorityQueue) *Waitlist {
+	waitlist := Waitlist{
+		queue:   queue,
+		queued:  make(map[string]*Asset, queue.Len()),
+		leased:  make(map[string]bool),
+		dropped: make(map[string]bool),
+	}
+	for _, asset := range *queue {
+		waitlist.queued[asset.Id()] = asset
+	}
+	return &waitlist
+}
+
+func (w *Waitlist) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.queue.Len()
+}
+
+// Lease takes the asset due first off the queue, if it is due by now.
+func (w *Waitlist) Lease(now time.Time) (*Asset, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.queue.Len() <= 0 {
+		return nil, errEmptyWaitlist
+	}
+	if w.queue.Peek().priority > now.UnixNano() {
+		return nil, errWaitlistNotDue
+	}
+
+	asset, err := w.queue.PriorityQueueRemove()
+	if err != nil {
+		return nil, err
+	}
+	delete(w.queued, asset.Id())
+	w.leased[asset.Id()] = true
+	return asset, nil
+}
+
+// Release ends the lease of an asset without queuing it again.
+func (w *Waitlist) Release(asset *Asset) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.leased, asset.Id())
+	delete(w.dropped, asset.Id())
+}
+
+// Dropped tells whether a leased asset was removed during its lease, untracked
+// or paused, and shouldn't be queued nor dead-lettered once its run ends.
+func (w *Waitlist) Dropped(assetId string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.dropped[assetId]
+}
+
+// Requeue ends the lease of an asset and queues it to run no earlier than
+// notBefore, held back by its refresh class. It reports false when the asset
+// was removed during its lease and has been dropped instead.
+func (w *Waitlist) Requeue(asset *Asset, notBefore time.Time) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.leased, asset.Id())
+	if w.dropped[asset.Id()] {
+		delete(w.dropped, asset.Id())
+		return false
+	}
+	w.push(asset, notBefore)
+	return true
+}
+
+// Push queues an asset to run no earlier than notBefore, held back by its
+// refresh class, unless it is queued or leased already.
+func (w *Waitlist) Push(asset *Asset, notBefore time.Time) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.queued[asset.Id()] != nil || w.leased[asset.Id()] {
+		return false
+	}
+	w.push(asset, notBefore)
+	return true
+}
+
+// Replace queues an asset in place of the queued one with the same id, if
+// any. Leased assets can't be replaced until their run ends.
+func (w *Waitlist) Replace(asset *Asset, notBefore time.Time) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.leased[asset.Id()] {
+		return ErrAssetRunning
+	}
+	if queued := w.queued[asset.Id()]; queued != nil {
+		w.queue.Remove(queued)
+		delete(w.queued, asset.Id())
+	}
+	w.push(asset, notBefore)
+	return nil
+}
+
+// Remove takes an asset off the queue, or drops it once its lease ends when
+// it is running. It reports false when the asset is neither.
+func (w *Waitlist) Remove(assetId string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if queued := w.queued[assetId]; queued != nil {
+		w.queue.Remove(queued)
+		delete(w.queued, assetId)
+		return true
+	}
+	if w.leased[assetId] {
+		w.dropped[assetId] = true
+		return true
+	}
+	return false
+}
+
+// Reprioritize makes a queued asset due at the given time, ignoring its
+// refresh class.
+func (w *Waitlist) Reprioritize(assetId string, due time.Time) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.leased[assetId] {
+		return ErrAssetRunning
+	}
+	queued := w.queued[assetId]
+	if queued == nil {
+		return ErrAssetNotTracked
+	}
+	w.queue.Update(queued, due.UnixNano())
+	return nil
+}
+
+// State tells whether an asset is queued or running, and is empty otherwise.
+func (w *Waitlist) State(assetId string) string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	switch {
+	case w.leased[assetId]:
+		return StateRunning
+	case w.queued[assetId] != nil:
+		return StateQueued
+	}
+	return ""
+}
+
+// Due is when a queued asset becomes due.
+func (w *Waitlist) Due(assetId string) (time.Time, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	queued := w.queued[assetId]
+	if queued == nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, queued.priority), true
+}
+
+func (w *Waitlist) push(asset *Asset, notBefore time.Time) {
+	w.queued[asset.Id()] = asset
+	w.queue.PriorityQueuePushAt(asset, asset.policy.due(notBefore, asset.lastSuccess))
+}
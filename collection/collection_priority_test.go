@@ -0,0 +1,89 @@
+package collection
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestWaitlist(now time.Time, addresses ...string) *Waitlist {
+	queue := make(PriorityQueue, 0, len(addresses))
+	waitlist := NewWaitlist(&queue)
+	for _, address := range addresses {
+		asset := NewAsset(1, address, 0, 0)
+		asset.policy = classPolicies[ClassHot]
+		waitlist.Push(asset, now)
+	}
+	return waitlist
+}
+
+func TestWaitlistLease(t *testing.T) {
+	now := time.Now()
+	waitlist := newTestWaitlist(now, "0x01", "0x02")
+
+	if _, err := waitlist.Lease(now.Add(-time.Second)); err != errWaitlistNotDue {
+		t.Fatalf("Lease before due = %v, want %v", err, errWaitlistNotDue)
+	}
+
+	first, err := waitlist.Lease(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := waitlist.Lease(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Id() == second.Id() {
+		t.Fatalf("Lease handed out %s twice", first.Id())
+	}
+	if _, err := waitlist.Lease(now); err != errEmptyWaitlist {
+		t.Fatalf("Lease of an empty waitlist = %v, want %v", err, errEmptyWaitlist)
+	}
+
+	// a leased asset can't be queued or moved until its lease ends
+	if waitlist.Push(first, now) {
+		t.Error("Push queued a leased asset")
+	}
+	if err := waitlist.Replace(first, now); err != ErrAssetRunning {
+		t.Errorf("Replace of a leased asset = %v, want %v", err, ErrAssetRunning)
+	}
+	if err := waitlist.Reprioritize(first.Id(), now); err != ErrAssetRunning {
+		t.Errorf("Reprioritize of a leased asset = %v, want %v", err, ErrAssetRunning)
+	}
+	if state := waitlist.State(first.Id()); state != StateRunning {
+		t.Errorf("State of a leased asset = %s, want %s", state, StateRunning)
+	}
+
+	if !waitlist.Requeue(first, now) {
+		t.Fatal("Requeue dropped a leased asset")
+	}
+	if state := waitlist.State(first.Id()); state != StateQueued {
+		t.Errorf("State of a requeued asset = %s, want %s", state, StateQueued)
+	}
+
+	waitlist.Release(second)
+	if state := waitlist.State(second.Id()); state != "" {
+		t.Errorf("State of a released asset = %s, want none", state)
+	}
+	if !waitlist.Push(second, now) {
+		t.Error("Push didn't queue a released asset")
+	}
+}
+
+func TestWaitlistRemoveLeased(t *testing.T) {
+	now := time.Now()
+	waitlist := newTestWaitlist(now, "0x01")
+
+	asset, err := waitlist.Lease(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !waitlist.Remove(asset.Id()) {
+		t.Fatal("Remove didn't find the leased asset")
+	}
+	if waitlist.Requeue(asset, now) {
+		t.Error("Requeue queued an asset removed while leased")
+	}
+	if waitlist.Len() != 0 || waitlist.State(asset.Id()) != "" {
+		t.Error("asset removed while leased is still tracked")
+	}
+}
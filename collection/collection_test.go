@@ -0,0 +1,71 @@
+package collection
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+func abiString(value string) []byte {
+	encoded := ethcommon.LeftPadBytes(big.NewInt(32).Bytes(), 32)
+	encoded = append(encoded, ethcommon.LeftPadBytes(big.NewInt(int64(len(value))).Bytes(), 32)...)
+	padded := make([]byte, (len(value)+31)/32*32)
+	copy(padded, value)
+	return append(encoded, padded...)
+}
+
+func TestSetBaseUri(t *testing.T) {
+	tests := []struct {
+		name   string
+		answer func([]byte) ([]byte, error)
+		uri    *Uri
+		err    error
+	}{
+		{
+			"ipfs base uri",
+			func([]byte) ([]byte, error) {
+				return abiString("ipfs://QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG/"), nil
+			},
+			&Uri{Scheme: UriIPFS, Host: "QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG"}, nil,
+		},
+		{
+			"empty base uri",
+			func([]byte) ([]byte, error) { return abiString(""), nil },
+			&Uri{Scheme: UriToken}, nil,
+		},
+		{
+			"data base uri",
+			func([]byte) ([]byte, error) { return abiString("data:application/json;base64,"), nil },
+			&Uri{Scheme: UriToken}, nil,
+		},
+		{
+			"reverting base uri",
+			func([]byte) ([]byte, error) { return nil, errors.New("execution reverted") },
+			&Uri{Scheme: UriToken}, nil,
+		},
+		{
+			"transport failure",
+			func([]byte) ([]byte, error) { return nil, errTestTransport },
+			nil, errTestTransport,
+		},
+	}
+
+	for _, test := range tests {
+		collection, err := NewCollection(ethcommon.Address{}, &testBackend{call: test.answer})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		asset := NewAsset(1, "0x01", 0, 0)
+		err = asset.setBaseUri(collection)
+		if err != test.err {
+			t.Errorf("%s: setBaseUri = %v, want %v", test.name, err, test.err)
+			continue
+		}
+		if (asset.uri == nil) != (test.uri == nil) || (asset.uri != nil && *asset.uri != *test.uri) {
+			t.Errorf("%s: uri = %+v, want %+v", test.name, asset.uri, test.uri)
+		}
+	}
+}
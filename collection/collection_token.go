@@ -0,0 +1,105 @@
+package collection
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+)
+
+var (
+	errTokenEnumerationFailed = errors.New("Couldn't enumerate the token ids of the collection")
+	errTokenIndexOutOfRange   = errors.New("Token index is out of range")
+	errTokenUriNotExist       = errors.New("Couldn't find the token uri")
+)
+
+// TokenRange is a known span of token ids, with End exclusive.
+type TokenRange struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// TokenEnumerator lists the token ids of a collection, through
+// ERC721Enumerable when the contract implements it and over a token range
+// otherwise.
+type TokenEnumerator struct {
+	collection *Collection
+
+	tokenRange *TokenRange
+	supply     int
+}
+
+// NewTokenEnumerator reads the total supply and picks how ids are listed,
+// from the capabilities of the asset when it declared them. Collections that
+// aren't enumerable and have no known range are assumed to hold contiguous
+// ids from zero or one, whichever resolves.
+func NewTokenEnumerator(collection *Collection, asset *Asset) (*TokenEnumerator, error) {
+	capabilities := asset.capabilities
+	if capabilities != 0 && !capabilities.Has(CapabilityMetadata) {
+		return nil, errMetadataUnsupported
+	}
+
+	totalSupply, err := collection.TotalSupply(&bind.CallOpts{})
+	if err != nil {
+		return nil, errTokenEnumerationFailed
+	}
+	asset.SetTotalSupply(*totalSupply)
+
+	e := TokenEnumerator{
+		collection: collection,
+		tokenRange: asset.tokenRange,
+		supply:     int(totalSupply.Int64()),
+	}
+	if e.tokenRange != nil {
+		return &e, nil
+	}
+
+	if capabilities.Has(CapabilityEnumerable) {
+		return &e, nil
+	}
+	if capabilities == 0 {
+		if _, err := collection.TokenByIndex(&bind.CallOpts{}, big.NewInt(0)); err == nil {
+			return &e, nil
+		}
+	}
+
+	for _, start := range []int64{0, 1} {
+		if _, err := collection.TokenURI(&bind.CallOpts{}, big.NewInt(start)); err == nil {
+			e.tokenRange = &TokenRange{Start: start, End: start + totalSupply.Int64()}
+			return &e, nil
+		}
+	}
+	return nil, errTokenEnumerationFailed
+}
+
+func (e *TokenEnumerator) Len() int {
+	if e.tokenRange != nil {
+		return int(e.tokenRange.End - e.tokenRange.Start)
+	}
+	return e.supply
+}
+
+// TokenId returns the id of the i-th token of the collection.
+func (e *TokenEnumerator) TokenId(i int) (*big.Int, error) {
+	if i < 0 || i >= e.Len() {
+		return nil, errTokenIndexOutOfRange
+	}
+	if e.tokenRange != nil {
+		return big.NewInt(e.tokenRange.Start + int64(i)), nil
+	}
+	return e.collection.TokenByIndex(&bind.CallOpts{}, big.NewInt(int64(i)))
+}
+
+// TokenURI returns the id and metadata uri of the i-th token.
+func (e *TokenEnumerator) TokenURI(i int) (*big.Int, string, error) {
+	id, err := e.TokenId(i)
+	if err != nil {
+		return nil, "", err
+	}
+
+	uri, err := e.collection.TokenURI(&bind.CallOpts{}, id)
+	if err != nil {
+		return nil, "", errTokenUriNotExist
+	}
+	return id, uri, nil
+}
@@ -0,0 +1,82 @@
+package collection
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+// sequentialCollection answers a totalSupply of supply, and a tokenURI for
+// the ids from first to first + supply - 1. Every other call reverts.
+func sequentialCollection(t *testing.T, first int64, supply int64) *Collection {
+	parsed, err := abi.JSON(strings.NewReader(CollectionABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reverted := errors.New("execution reverted")
+
+	call := func(data []byte) ([]byte, error) {
+		method, err := parsed.MethodById(data[:4])
+		if err != nil {
+			return nil, reverted
+		}
+		switch method.Name {
+		case "totalSupply":
+			return ethcommon.LeftPadBytes(big.NewInt(supply).Bytes(), 32), nil
+		case "tokenURI":
+			id := new(big.Int).SetBytes(data[4:36]).Int64()
+			if id < first || id >= first+supply {
+				return nil, reverted
+			}
+			return abiString("https://api.example.com/" + big.NewInt(id).String()), nil
+		}
+		return nil, reverted
+	}
+
+	collection, err := NewCollection(ethcommon.Address{}, &testBackend{call: call})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return collection
+}
+
+func TestTokenEnumeratorRange(t *testing.T) {
+	tests := []struct {
+		name       string
+		first      int64
+		tokenRange *TokenRange
+		ids        []int64
+	}{
+		{"ids from zero", 0, nil, []int64{0, 1, 2}},
+		{"ids from one", 1, nil, []int64{1, 2, 3}},
+		{"configured range", 5, &TokenRange{Start: 5, End: 8}, []int64{5, 6, 7}},
+	}
+
+	for _, test := range tests {
+		asset := NewAsset(1, "0x0000000000000000000000000000000000000001", 0, 0)
+		asset.tokenRange = test.tokenRange
+
+		enumerator, err := NewTokenEnumerator(sequentialCollection(t, test.first, 3), asset)
+		if err != nil {
+			t.Errorf("%s: NewTokenEnumerator = %v", test.name, err)
+			continue
+		}
+		if enumerator.Len() != len(test.ids) {
+			t.Errorf("%s: Len = %d, want %d", test.name, enumerator.Len(), len(test.ids))
+			continue
+		}
+		for i, want := range test.ids {
+			id, err := enumerator.TokenId(i)
+			if err != nil || id.Int64() != want {
+				t.Errorf("%s: TokenId(%d) = %v %v, want %d", test.name, i, id, err, want)
+			}
+		}
+		if _, err := enumerator.TokenId(len(test.ids)); err != errTokenIndexOutOfRange {
+			t.Errorf("%s: TokenId past the range = %v, want %v", test.name, err, errTokenIndexOutOfRange)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package collection
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	defaultConcurrency = 8
+)
+
+// CrawlConfig controls how the tokens of a collection are walked.
+type CrawlConfig struct {
+	// Stride samples every n-th token; a stride of one crawls every token.
+	Stride int
+	// Concurrency bounds the requests in flight, keyed by the Uri scheme of
+	// the collection being crawled.
+	Concurrency map[int]int
+}
+
+// TokenFetch reads the metadata of the i-th token of a collection.
+type TokenFetch func(i int) (*Token, error)
+
+func DefaultCrawlConfig() CrawlConfig {
+	return CrawlConfig{
+		Stride: 1,
+		Concurrency: map[int]int{
+			UriIPFS:    8,
+			UriHttp:    16,
+			UriArweave: 8,
+			UriToken:   8,
+		},
+	}
+}
+
+// Workers returns the concurrency configured for a Uri scheme.
+func (config CrawlConfig) Workers(scheme int) int {
+	if n := config.Concurrency[scheme]; n > 0 {
+		return n
+	}
+	return defaultConcurrency
+}
+
+// Crawl fetches tokens [0, total) through a pool of workers, folds each one
+// into the trait and returns the tokens read, with the trait finalized over
+// them. Tokens which fail are logged and left out of the trait's coverage;
+// the crawl only fails when none of them could be read.
+func Crawl(trait *Trait, total int, stride int, workers int, fetch TokenFetch) ([]*Token, error) {
+	if stride < 1 {
+		stride = 1
+	}
+	trait.Total = (total + stride - 1) / stride
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		tokens   []*Token
+	)
+
+	indexes := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				token, err := fetch(i)
+				if err != nil {
+					log.Printf("[WARN]: Token %d failed %s", i, err)
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+					continue
+				}
+				token.FetchedAt = time.Now()
+				BuildWeightedTrait(&token.Attributes, trait, token.weight())
+
+				mu.Lock()
+				tokens = append(tokens, token)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i := 0; i < total; i += stride {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	if trait.Total > 0 && trait.Index == 0 {
+		return nil, firstErr
+	}
+	trait.Finalize(tokens)
+	return tokens, nil
+}
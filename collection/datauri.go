@@ -0,0 +1,156 @@
+package collection
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"strings"
+)
+
+var (
+	errDataUriFormat    = errors.New("Data URI is malformed")
+	errDataUriMediaType = errors.New("Data URI doesn't hold a metadata document")
+
+	ErrImageNotInline       = errors.New("Token image isn't inlined in its metadata")
+	ErrImageTypeUnsupported = errors.New("Token image isn't of a servable image type")
+)
+
+const (
+	dataUriPrefix   = "data:"
+	defaultDataType = "text/plain"
+
+	MediaTypeJSON = "application/json"
+	MediaTypeSVG  = "image/svg+xml"
+)
+
+// metadataTypes are the media types a metadata document is inlined as.
+var metadataTypes = map[string]bool{
+	MediaTypeJSON: true,
+	"text/json":   true,
+	"text/plain":  true,
+}
+
+// imageTypes are the media types an inline image is served as. Anything
+// else, such as text/html, could run as active content and is refused.
+var imageTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+	MediaTypeSVG: true,
+}
+
+// DataUri is a decoded RFC 2397 data uri, as returned by the tokenURI of
+// fully on-chain collections.
+type DataUri struct {
+	MediaType string
+	Data      []byte
+}
+
+// Data reads data uris, decoding their content in place without any network
+// access.
+type Data struct{}
+
+// IsDataUri reports whether uri embeds its content.
+func IsDataUri(uri string) bool {
+	return len(uri) >= len(dataUriPrefix) && strings.EqualFold(uri[:len(dataUriPrefix)], dataUriPrefix)
+}
+
+// ParseDataUri decodes a "data:[<media type>][;<param>]*[;base64],<data>"
+// uri. Content which isn't base64 is percent-decoded when it can be, and
+// taken as written otherwise, since contracts often inline raw JSON.
+func ParseDataUri(uri string) (*DataUri, error) {
+	if !IsDataUri(uri) {
+		return nil, errDataUriFormat
+	}
+
+	comma := strings.IndexByte(uri, ',')
+	if comma < 0 {
+		return nil, errDataUriFormat
+	}
+	header, content := uri[len(dataUriPrefix):comma], uri[comma+1:]
+
+	params := strings.Split(header, ";")
+	mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+	if mediaType == "" {
+		mediaType = defaultDataType
+	}
+
+	encoded := false
+	for _, param := range params[1:] {
+		if strings.EqualFold(strings.TrimSpace(param), "base64") {
+			encoded = true
+		}
+	}
+
+	d := DataUri{MediaType: mediaType}
+	if encoded {
+		data, err := decodeBase64(content)
+		if err != nil {
+			return nil, errDataUriFormat
+		}
+		d.Data = data
+		return &d, nil
+	}
+
+	if unescaped, err := url.PathUnescape(content); err == nil {
+		content = unescaped
+	}
+	d.Data = []byte(content)
+	return &d, nil
+}
+
+// decodeBase64 reads standard or url-safe base64, padded or not.
+func decodeBase64(content string) ([]byte, error) {
+	content = strings.TrimRight(strings.TrimSpace(content), "=")
+	if strings.ContainsAny(content, "-_") {
+		return base64.RawURLEncoding.DecodeString(content)
+	}
+	return base64.RawStdEncoding.DecodeString(content)
+}
+
+// Get decodes the metadata document embedded in a data uri. A uri inlining
+// an image, such as an SVG, reads as a document holding only that image.
+func (data Data) Get(uri string) (io.ReadCloser, error) {
+	d, err := ParseDataUri(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case metadataTypes[d.MediaType]:
+		return ioutil.NopCloser(bytes.NewReader(d.Data)), nil
+	case strings.HasPrefix(d.MediaType, "image/"):
+		document, err := json.Marshal(&Metadata{Image: uri})
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.NopCloser(bytes.NewReader(document)), nil
+	}
+	return nil, errDataUriMediaType
+}
+
+// InlineImage returns the image embedded in the metadata, either as the
+// raw SVG of image_data or as a data uri in image, along with its media type.
+// Images which aren't of an allowed image type are refused.
+func (m *Metadata) InlineImage() ([]byte, string, error) {
+	if m.ImageData != "" {
+		return []byte(m.ImageData), MediaTypeSVG, nil
+	}
+	if !IsDataUri(m.Image) {
+		return nil, "", ErrImageNotInline
+	}
+
+	d, err := ParseDataUri(m.Image)
+	if err != nil {
+		return nil, "", err
+	}
+	if !imageTypes[d.MediaType] {
+		return nil, "", ErrImageTypeUnsupported
+	}
+	return d.Data, d.MediaType, nil
+}
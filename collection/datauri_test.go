@@ -0,0 +1,90 @@
+package collection
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"testing"
+)
+
+func TestParseDataUri(t *testing.T) {
+	document := `{"name":"On chain 100%"}`
+	tests := []struct {
+		uri       string
+		mediaType string
+		data      string
+		err       error
+	}{
+		{"data:application/json;base64," + base64.StdEncoding.EncodeToString([]byte(document)), "application/json", document, nil},
+		{"data:application/json;base64," + base64.RawURLEncoding.EncodeToString([]byte("{\"a\":\"??>\"}")), "application/json", "{\"a\":\"??>\"}", nil},
+		{"data:application/json;utf8," + document, "application/json", document, nil},
+		{"data:application/json," + "%7B%22name%22%3A%22x%22%7D", "application/json", `{"name":"x"}`, nil},
+		{"DATA:Image/SVG+XML;charset=utf-8;BASE64," + base64.StdEncoding.EncodeToString([]byte("<svg/>")), "image/svg+xml", "<svg/>", nil},
+		{"data:,plain", "text/plain", "plain", nil},
+		{"data:application/json;base64,!!!", "", "", errDataUriFormat},
+		{"data:application/json", "", "", errDataUriFormat},
+		{"https://example.com/1.json", "", "", errDataUriFormat},
+	}
+
+	for _, test := range tests {
+		d, err := ParseDataUri(test.uri)
+		if err != test.err {
+			t.Errorf("ParseDataUri(%q) error = %v, want %v", test.uri, err, test.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if d.MediaType != test.mediaType || string(d.Data) != test.data {
+			t.Errorf("ParseDataUri(%q) = %s %q, want %s %q", test.uri, d.MediaType, d.Data, test.mediaType, test.data)
+		}
+	}
+}
+
+func TestDataGet(t *testing.T) {
+	tests := []struct {
+		uri  string
+		body string
+		err  error
+	}{
+		{"data:application/json;utf8,{\"name\":\"x\"}", `{"name":"x"}`, nil},
+		{"data:image/svg+xml;utf8,<svg/>", `{"image":"data:image/svg+xml;utf8,\u003csvg/\u003e","attributes":null}`, nil},
+		{"data:text/html,<script>alert(1)</script>", "", errDataUriMediaType},
+	}
+
+	for _, test := range tests {
+		res, err := Data{}.Get(test.uri)
+		if err != test.err {
+			t.Errorf("Get(%q) error = %v, want %v", test.uri, err, test.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		body, _ := ioutil.ReadAll(res)
+		if string(body) != test.body {
+			t.Errorf("Get(%q) = %s, want %s", test.uri, body, test.body)
+		}
+	}
+}
+
+func TestInlineImage(t *testing.T) {
+	tests := []struct {
+		metadata  Metadata
+		mediaType string
+		err       error
+	}{
+		{Metadata{Image: "data:image/png;base64,iVBORw0KGgo="}, "image/png", nil},
+		{Metadata{Image: "data:image/svg+xml;utf8,<svg/>"}, MediaTypeSVG, nil},
+		{Metadata{ImageData: "<svg/>"}, MediaTypeSVG, nil},
+		{Metadata{Image: "data:text/html,<script>alert(1)</script>"}, "", ErrImageTypeUnsupported},
+		{Metadata{Image: "data:application/xhtml+xml,<html/>"}, "", ErrImageTypeUnsupported},
+		{Metadata{Image: "ipfs://QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG"}, "", ErrImageNotInline},
+	}
+
+	for _, test := range tests {
+		_, mediaType, err := test.metadata.InlineImage()
+		if err != test.err || mediaType != test.mediaType {
+			t.Errorf("InlineImage(%+v) = %s %v, want %s %v", test.metadata, mediaType, err, test.mediaType, test.err)
+		}
+	}
+}
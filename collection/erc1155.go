@@ -0,0 +1,281 @@
+package collection
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+
+	"github.com/levelabs/level-go/store"
+)
+
+// SupplyIndexer replays the TransferSingle and TransferBatch logs of an
+// ERC-1155 collection into the circulating supply of every token id and the
+// balance of every holder. Only confirmed blocks are read, so the supplies
+// never need to be rolled back.
+type SupplyIndexer struct {
+	client *Client
+	store  *store.Store
+
+	MinChunk uint64
+	MaxChunk uint64
+
+	// DeployBlocks are the configured deployment blocks by asset id, used
+	// when the node can't locate them.
+	DeployBlocks map[string]uint64
+}
+
+// transfer1155 is a single id moved by either kind of ERC-1155 transfer.
+type transfer1155 struct {
+	From  ethcommon.Address
+	To    ethcommon.Address
+	Id    *big.Int
+	Value *big.Int
+}
+
+func NewSupplyIndexer(client *Client, s *store.Store) *SupplyIndexer {
+	return &SupplyIndexer{
+		client:   client,
+		store:    s,
+		MinChunk: defaultMinChunk,
+		MaxChunk: defaultMaxChunk,
+	}
+}
+
+// ExpandTokenUri substitutes the {id} placeholder of an ERC-1155 uri with the
+// token id, as lower case hex zero padded to 64 characters.
+func ExpandTokenUri(uri string, id *big.Int) string {
+	return strings.ReplaceAll(uri, "{id}", fmt.Sprintf("%064x", id))
+}
+
+// Backfill reads the transfers from the last checkpoint up to the confirmed
+// head of the chain.
+func (indexer *SupplyIndexer) Backfill(ctx context.Context, asset *Asset) error {
+	assetId := asset.Id()
+
+	ethereum, err := indexer.client.Chain(asset.chainId)
+	if err != nil {
+		return err
+	}
+
+	filterer, err := NewCollection1155Filterer(asset.address, ethereum.Client)
+	if err != nil {
+		return errCreatingCollectionEthBinding
+	}
+
+	head, err := ethereum.Client.BlockNumber(ctx)
+	if err != nil {
+		return err
+	}
+	head = confirmedBelow(head, confirmations(ethereum))
+
+	key := store.SupplyCheckpointKey(assetId)
+	checkpoint, err := loadCheckpoint(ctx, indexer.store, key, ethereum.Client, asset, head, indexer.DeployBlocks[assetId])
+	if err != nil {
+		return err
+	}
+
+	from := checkpoint.Block + 1
+	if checkpoint.Block < checkpoint.Deployed {
+		from = checkpoint.Deployed
+	}
+
+	chunk := uint64(initialChunk)
+	for from <= head {
+		to := from + chunk - 1
+		if to > head {
+			to = head
+		}
+
+		transfers, err := filterTransfers1155(ctx, filterer, from, to)
+		if err != nil {
+			if chunk <= indexer.MinChunk {
+				return errChunkTooSmall
+			}
+			chunk /= 2
+			continue
+		}
+
+		checkpoint.Block = to
+		checkpoint.Confirmed = to
+		if err := indexer.apply(assetId, transfers, checkpoint); err != nil {
+			return err
+		}
+		log.Printf("[SUPPLY]: %s:%d-%d %d transfers\n", assetId, from, to, len(transfers))
+
+		from = to + 1
+		if chunk < indexer.MaxChunk {
+			chunk *= 2
+		}
+	}
+	return nil
+}
+
+func filterTransfers1155(
+	ctx context.Context,
+	filterer *Collection1155Filterer,
+	from uint64,
+	to uint64,
+) ([]transfer1155, error) {
+	opts := &bind.FilterOpts{Start: from, End: &to, Context: ctx}
+
+	var transfers []transfer1155
+
+	singles, err := filterer.FilterTransferSingle(opts, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer singles.Close()
+	for singles.Next() {
+		event := singles.Event
+		transfers = append(transfers, transfer1155{From: event.From, To: event.To, Id: event.Id, Value: event.Value})
+	}
+	if err := singles.Error(); err != nil {
+		return nil, err
+	}
+
+	batches, err := filterer.FilterTransferBatch(opts, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer batches.Close()
+	for batches.Next() {
+		event := batches.Event
+		for i := range event.Ids {
+			if i >= len(event.Values) {
+				break
+			}
+			transfers = append(transfers, transfer1155{From: event.From, To: event.To, Id: event.Ids[i], Value: event.Values[i]})
+		}
+	}
+	if err := batches.Error(); err != nil {
+		return nil, err
+	}
+	return transfers, nil
+}
+
+// apply sums the transfers into supply and balance changes and writes them
+// with the checkpoint. Mints add to the supply of an id and burns take from
+// it. Amounts are additive, so the order of the transfers doesn't matter.
+func (indexer *SupplyIndexer) apply(assetId string, transfers []transfer1155, checkpoint *Checkpoint) error {
+	zero := ethcommon.Address{}
+
+	supplies := make(map[string]*big.Int)
+	balances := make(map[string]*big.Int)
+	add := func(amounts map[string]*big.Int, key []byte, delta *big.Int) error {
+		amount, ok := amounts[string(key)]
+		if !ok {
+			var err error
+			if amount, err = loadAmount(indexer.store, key); err != nil {
+				return err
+			}
+			amounts[string(key)] = amount
+		}
+		amount.Add(amount, delta)
+		return nil
+	}
+
+	for _, transfer := range transfers {
+		id := transfer.Id.String()
+		negative := new(big.Int).Neg(transfer.Value)
+
+		if transfer.From == zero {
+			if err := add(supplies, store.SupplyKey(assetId, id), transfer.Value); err != nil {
+				return err
+			}
+		} else if err := add(balances, store.BalanceKey(assetId, transfer.From.Hex(), id), negative); err != nil {
+			return err
+		}
+
+		if transfer.To == zero {
+			if err := add(supplies, store.SupplyKey(assetId, id), negative); err != nil {
+				return err
+			}
+		} else if err := add(balances, store.BalanceKey(assetId, transfer.To.Hex(), id), transfer.Value); err != nil {
+			return err
+		}
+	}
+
+	return indexer.store.WriteBatch(func(batch *store.Batch) error {
+		for _, amounts := range []map[string]*big.Int{supplies, balances} {
+			for key, amount := range amounts {
+				if amount.Sign() <= 0 {
+					if err := batch.Delete([]byte(key)); err != nil {
+						return err
+					}
+					continue
+				}
+				if err := batch.Set([]byte(key), amount.String()); err != nil {
+					return err
+				}
+			}
+		}
+		return batch.Set(store.SupplyCheckpointKey(assetId), checkpoint)
+	})
+}
+
+func loadAmount(s *store.Store, key []byte) (*big.Int, error) {
+	var amount string
+	err := s.Get(key, &amount)
+	if err == store.ErrNotFound {
+		return new(big.Int), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	value, ok := new(big.Int).SetString(amount, 10)
+	if !ok {
+		return new(big.Int), nil
+	}
+	return value, nil
+}
+
+// TokenSupply is the circulating supply of one ERC-1155 token id.
+type TokenSupply struct {
+	Id     *big.Int
+	Supply *big.Int
+}
+
+// LoadSupplies reads the supply of every id in circulation, ordered by id.
+func LoadSupplies(s *store.Store, assetId string) ([]TokenSupply, error) {
+	prefix := store.SupplyPrefix(assetId)
+
+	var supplies []TokenSupply
+	err := s.Iterate(prefix, func(key []byte, value []byte) error {
+		id, ok := new(big.Int).SetString(strings.TrimPrefix(string(key), string(prefix)), 10)
+		if !ok {
+			return nil
+		}
+
+		var amount string
+		if err := json.Unmarshal(value, &amount); err != nil {
+			return err
+		}
+		supply, ok := new(big.Int).SetString(amount, 10)
+		if !ok {
+			return nil
+		}
+		supplies = append(supplies, TokenSupply{Id: id, Supply: supply})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(supplies, func(i, j int) bool {
+		return supplies[i].Id.Cmp(supplies[j].Id) < 0
+	})
+	return supplies, nil
+}
+
+// Balance is the amount of an ERC-1155 token id held by holder.
+func Balance(s *store.Store, assetId string, holder string, id string) (*big.Int, error) {
+	return loadAmount(s, store.BalanceKey(assetId, holder, id))
+}
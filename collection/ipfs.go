@@ -0,0 +1,160 @@
+package collection
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	net "net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+)
+
+var (
+	errIPFSPathInvalid = errors.New("IPFS path doesn't start with a valid CID")
+)
+
+const (
+	ipfsNamespace = "ipfs"
+
+	// ipfsGatewayTimeout bounds a request to a single gateway, so a stalled
+	// one doesn't hold back the ones after it.
+	ipfsGatewayTimeout = 30 * time.Second
+
+	// ipfsWarnInterval spaces out the warnings of a failing node, which
+	// would otherwise be logged for every token.
+	ipfsWarnInterval = 5 * time.Minute
+)
+
+// ParseIPFSPath reads the root cid and the path below it out of the ways an
+// IPFS file gets addressed: "ipfs://<cid>/<path>", "ipfs://ipfs/<cid>/<path>",
+// "/ipfs/<cid>/<path>" or a bare "<cid>/<path>". Both CIDv0 and CIDv1 roots
+// are accepted.
+func ParseIPFSPath(path string) (*Uri, error) {
+	path = strings.TrimPrefix(path, "ipfs://")
+	path = strings.TrimPrefix(strings.TrimLeft(path, "/"), ipfsNamespace+"/")
+
+	root, rest := splitRoot(path)
+	if !isCid(root) {
+		return nil, errIPFSPathInvalid
+	}
+	return &Uri{Scheme: UriIPFS, Host: root, Path: rest}, nil
+}
+
+// parseIPFSGateway recognizes an http url served by an IPFS gateway, either
+// path style "https://<gateway>/ipfs/<cid>/<path>" or subdomain style
+// "https://<cid>.ipfs.<gateway>/<path>", so the file is read through IPFS
+// instead of the gateway it was published with.
+func parseIPFSGateway(base *url.URL) (*Uri, bool) {
+	labels := strings.SplitN(base.Hostname(), ".", 3)
+	if len(labels) == 3 && labels[1] == ipfsNamespace && isCid(labels[0]) {
+		return &Uri{Scheme: UriIPFS, Host: labels[0], Path: strings.TrimPrefix(base.Path, "/")}, true
+	}
+
+	if !strings.HasPrefix(base.Path, "/"+ipfsNamespace+"/") {
+		return nil, false
+	}
+	u, err := ParseIPFSPath(base.Path)
+	if err != nil {
+		return nil, false
+	}
+	return u, true
+}
+
+func splitRoot(path string) (string, string) {
+	parts := strings.SplitN(path, "/", 2)
+	if len(parts) < 2 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+func isCid(root string) bool {
+	if root == "" {
+		return false
+	}
+	_, err := cid.Decode(root)
+	return err == nil
+}
+
+// Get reads a "<cid>/<path>" file through the IPFS node, and through each
+// configured gateway in turn when the node can't serve it.
+func (ipfs IPFS) Get(uri string) (io.ReadCloser, error) {
+	if ipfs.Client != nil {
+		res, err := ipfs.Client.Cat(uri)
+		if err == nil {
+			return res, nil
+		}
+		if len(ipfs.Gateways) == 0 {
+			return nil, errClientIPFSGet
+		}
+		ipfs.warnings.Printf("[WARN]: IPFS node couldn't serve %s, falling back to gateways %s", uri, err)
+	}
+
+	for _, gateway := range ipfs.Gateways {
+		res, err := ipfs.getGateway(gateway, uri)
+		if err == nil {
+			return res, nil
+		}
+	}
+	return nil, errClientIPFSGet
+}
+
+func (ipfs IPFS) getGateway(gateway string, uri string) (io.ReadCloser, error) {
+	segments := strings.Split(uri, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	res, err := ipfs.Http.Get(gateway + "/" + ipfsNamespace + "/" + strings.Join(segments, "/"))
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != net.StatusOK {
+		res.Body.Close()
+		return nil, errClientIPFSGet
+	}
+	return res.Body, nil
+}
+
+// throttledLog prints at most one message per interval, counting the ones
+// dropped in between. A nil throttledLog prints every message.
+type throttledLog struct {
+	interval time.Duration
+
+	mu      sync.Mutex
+	last    time.Time
+	dropped int
+}
+
+func newThrottledLog(interval time.Duration) *throttledLog {
+	return &throttledLog{interval: interval}
+}
+
+func (l *throttledLog) Printf(format string, v ...interface{}) {
+	if l == nil {
+		log.Printf(format, v...)
+		return
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if !l.last.IsZero() && now.Sub(l.last) < l.interval {
+		l.dropped++
+		l.mu.Unlock()
+		return
+	}
+	dropped := l.dropped
+	l.last, l.dropped = now, 0
+	l.mu.Unlock()
+
+	message := fmt.Sprintf(format, v...)
+	if dropped > 0 {
+		message += fmt.Sprintf(" (%d more since the last warning)", dropped)
+	}
+	log.Print(message)
+}
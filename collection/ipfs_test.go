@@ -0,0 +1,93 @@
+package collection
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testCidV0 = "QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG"
+	testCidV1 = "bafybeigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbzdi"
+)
+
+func TestParseIPFSPath(t *testing.T) {
+	tests := []struct {
+		path string
+		uri  *Uri
+		err  error
+	}{
+		{"ipfs://" + testCidV0 + "/1.json", &Uri{Scheme: UriIPFS, Host: testCidV0, Path: "1.json"}, nil},
+		{"ipfs://ipfs/" + testCidV0 + "/a/1", &Uri{Scheme: UriIPFS, Host: testCidV0, Path: "a/1"}, nil},
+		{"/ipfs/" + testCidV1 + "/", &Uri{Scheme: UriIPFS, Host: testCidV1, Path: ""}, nil},
+		{testCidV1, &Uri{Scheme: UriIPFS, Host: testCidV1}, nil},
+		{"ipfs://", nil, errIPFSPathInvalid},
+		{"ipfs://notacid/1", nil, errIPFSPathInvalid},
+	}
+	for _, test := range tests {
+		uri, err := ParseIPFSPath(test.path)
+		if err != test.err {
+			t.Errorf("ParseIPFSPath(%q) = %v, want %v", test.path, err, test.err)
+			continue
+		}
+		if err == nil && *uri != *test.uri {
+			t.Errorf("ParseIPFSPath(%q) = %+v, want %+v", test.path, uri, test.uri)
+		}
+	}
+}
+
+func TestParseUri(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want *Uri
+		err  error
+	}{
+		{"ipfs://" + testCidV0 + "/", &Uri{Scheme: UriIPFS, Host: testCidV0}, nil},
+		{"https://ipfs.io/ipfs/" + testCidV0 + "/7", &Uri{Scheme: UriIPFS, Host: testCidV0, Path: "7"}, nil},
+		{"https://" + testCidV1 + ".ipfs.dweb.link/7", &Uri{Scheme: UriIPFS, Host: testCidV1, Path: "7"}, nil},
+		{"/ipfs/" + testCidV0 + "/7", &Uri{Scheme: UriIPFS, Host: testCidV0, Path: "7"}, nil},
+		{"ar://abc/tokens/", &Uri{Scheme: UriArweave, Host: "abc", Path: "tokens/"}, nil},
+		{"https://arweave.net/abc/7", &Uri{Scheme: UriArweave, Host: "abc", Path: "7"}, nil},
+		{"https://api.example.com/token/7", &Uri{Scheme: UriHttp, Host: "https://api.example.com/token/"}, nil},
+		{"data:application/json;base64,e30=", &Uri{Scheme: UriData}, nil},
+		{"ftp://example.com/7", nil, errURIFormatNotFound},
+		{"token/7", nil, errURIFormatNotFound},
+	}
+	for _, test := range tests {
+		uri, err := ParseUri(test.uri)
+		if err != test.err {
+			t.Errorf("ParseUri(%q) = %v, want %v", test.uri, err, test.err)
+			continue
+		}
+		if err == nil && *uri != *test.want {
+			t.Errorf("ParseUri(%q) = %+v, want %+v", test.uri, uri, test.want)
+		}
+	}
+}
+
+func TestThrottledLog(t *testing.T) {
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	log.SetFlags(0)
+	defer func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.LstdFlags)
+	}()
+
+	warnings := newThrottledLog(time.Hour)
+	for i := 0; i < 3; i++ {
+		warnings.Printf("failed %d", i)
+	}
+	if lines := strings.Count(out.String(), "\n"); lines != 1 {
+		t.Fatalf("logged %d lines within the interval, want 1:\n%s", lines, out.String())
+	}
+
+	warnings.last = time.Now().Add(-2 * time.Hour)
+	warnings.Printf("failed %d", 3)
+	if !strings.HasSuffix(out.String(), "failed 3 (2 more since the last warning)\n") {
+		t.Errorf("log after the interval = %q, want the dropped count", out.String())
+	}
+}
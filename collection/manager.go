@@ -1,93 +1,211 @@
 package collection
 
 import (
+	"context"
 	"errors"
 	"log"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
 
-	"github.com/levelabs/level-go/common"
-)
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 
-const (
-	ethUri  = "https://mainnet.infura.io/v3/79808cbe443249a8bc8bf46dea32b6f5"
-	ipfsUri = "localhost:5001"
+	"github.com/levelabs/level-go/common"
+	"github.com/levelabs/level-go/store"
 )
 
 var (
 	errEmptyWaitlist     = errors.New("Waitlist is empty")
+	errWaitlistNotDue    = errors.New("No collection on the waitlist is due yet")
 	errAttributesUpdated = errors.New("Attributes have been updated")
 )
 
 type Manager struct {
 	Connection *Client
-	Waitlist   *PriorityQueue
-}
+	Waitlist   *Waitlist
+	Store      *store.Store
+	Supply     *SupplyIndexer
+
+	Crawl CrawlConfig
+	Retry RetryPolicy
 
-type Attribute struct {
-	Trait string `json:"trait_type"`
-	Value string `json:"value"`
+	// TokenRanges are the configured token ranges by asset id, listing the
+	// ids of collections which can't be enumerated.
+	TokenRanges map[string]TokenRange
 }
 
+// Token is the metadata of a single token. Weight is the number of copies
+// it stands for in the trait counts, its supply for ERC-1155 tokens.
 type Token struct {
-	Image      string      `json:"image"`
-	Attributes []Attribute `json:"attributes"`
+	Id        string    `json:"-"`
+	FetchedAt time.Time `json:"-"`
+	Weight    float64   `json:"-"`
+
+	Metadata
 }
 
-func NewManager(
-	assets map[string]int64,
-) (*Manager, error) {
-	clientConfig := ClientConfig{
-		EthUri:  ethUri,
-		IPFSUri: ipfsUri,
+// UnmarshalJSON reads the token's metadata document, see ParseMetadata.
+func (token *Token) UnmarshalJSON(data []byte) error {
+	metadata, err := ParseMetadata(data)
+	if err != nil {
+		return err
 	}
+	token.Metadata = *metadata
+	return nil
+}
 
+// NewManager connects the clients and restores the waitlist, tracking the
+// seed collections, keyed by asset id, the first time they are configured.
+func NewManager(
+	clientConfig ClientConfig,
+	seeds map[string]RefreshPolicy,
+	s *store.Store,
+) (*Manager, error) {
 	client, err := BuildClient(clientConfig)
 	if err != nil {
 		// todo: should fail
 		return nil, err
 	}
 
-	waitlist := NewPriorityQueue(assets)
+	waitlist, err := RestoreWaitlist(s, seeds)
+	if err != nil {
+		return nil, err
+	}
 
 	manager := Manager{
 		Connection: client,
 		Waitlist:   waitlist,
+		Store:      s,
+		Supply:     NewSupplyIndexer(client, s),
+		Crawl:      DefaultCrawlConfig(),
+		Retry:      DefaultRetryPolicy(),
 	}
 
 	return &manager, nil
 }
 
-func (manager *Manager) RunSequence() (*Asset, error) {
-	if manager.Waitlist.Len() <= 0 {
-		return nil, errEmptyWaitlist
-	}
-
+// RunSequence crawls the collection due first, stores it and hands it to
+// handle. It is safe to call from several workers, each one holding the lease
+// of the collection it runs until it has been stored and handled, so no other
+// worker can touch the asset meanwhile.
+func (manager *Manager) RunSequence(handle SequenceHandler) (*Asset, error) {
 	asset, err := manager.WaitlistRemove()
 	if err != nil {
 		return nil, err
 	}
-	log.Printf("[SEQUENCE]: %s:%.2d\n", asset.address, asset.priority)
+	log.Printf("[SEQUENCE]: %s:%.2d\n", asset.Id(), asset.priority)
 
+	start := time.Now()
 	trait, err := manager.UpdateAttributes(asset)
+	asset.lastRun = start
+	asset.lastDuration = time.Since(start)
 	if err != nil {
-		manager.WaitlistAppend(asset)
+		manager.fail(asset, err)
 		return nil, err
 	}
 
 	asset.trait = trait
+	if err := manager.save(asset); err != nil {
+		manager.fail(asset, err)
+		return nil, err
+	}
+
+	// failures are only forgotten once the run is stored
+	asset.attempts = 0
+	asset.lastTokens = len(asset.tokens)
+	asset.lastResult = ResultOk
+	asset.lastSuccess = time.Now()
+	asset.tokens = nil
+	log.Printf("[CRAWL]: %s:%d/%d\n", asset.Id(), trait.Index, trait.Total)
+
+	if handle != nil {
+		handle(asset)
+	}
+	manager.reschedule(asset)
 
 	return asset, nil
 }
 
+// fail records a failed run of a leased asset and gives it to the retry
+// policy.
+func (manager *Manager) fail(asset *Asset, err error) {
+	asset.attempts++
+	asset.lastTokens = 0
+	asset.lastResult = ResultFailed
+	asset.lastError = err.Error()
+	manager.retry(asset, err)
+}
+
+// save stores the snapshot and tokens of a sequenced asset. Once stored the
+// tokens are read back from the store, so callers drop them from the asset.
+func (manager *Manager) save(asset *Asset) error {
+	if err := SaveAsset(manager.Store, asset); err != nil {
+		return err
+	}
+	return SaveTokens(manager.Store, asset)
+}
+
+// Index sequences one collection once, outside of the waitlist, and stores
+// its snapshot and tokens.
+func (manager *Manager) Index(assetId string) (*Asset, error) {
+	id, err := NormalizeAssetId(assetId)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := WaitlistEntry{Collection: id}
+	asset, err := entry.Asset(manager.Store)
+	if err != nil {
+		return nil, err
+	}
+
+	trait, err := manager.UpdateAttributes(asset)
+	if err != nil {
+		return nil, err
+	}
+	asset.trait = trait
+
+	if err := manager.save(asset); err != nil {
+		return nil, err
+	}
+	asset.tokens = nil
+	return asset, nil
+}
+
 func (manager *Manager) UpdateAttributes(asset *Asset) (*Trait, error) {
-	err := asset.SetBaseUri(&manager.Connection.Ethereum)
+	ethereum, err := manager.Connection.Chain(asset.chainId)
 	if err != nil {
-		// Handle Errors Here!
 		return nil, err
 	}
 
+	if tokenRange, ok := manager.TokenRanges[asset.Id()]; ok {
+		asset.SetTokenRange(tokenRange.Start, tokenRange.End)
+	}
+
+	// the contract is probed once, its capabilities picking the strategy
+	if asset.standard == StandardUnknown {
+		if err := asset.Probe(ethereum); err != nil {
+			return nil, err
+		}
+		log.Printf("[PROBE]: %s:%v\n", asset.Id(), asset.capabilities.Names())
+	}
+
 	trait := NewTrait()
 
-	// todo: add arweave getter
+	if asset.standard == StandardERC1155 {
+		if err := manager.RunERC1155TraitGetter(trait, asset); err != nil {
+			return nil, err
+		}
+		return trait, nil
+	}
+
+	err = asset.SetBaseUri(ethereum)
+	if err != nil {
+		// Handle Errors Here!
+		return nil, err
+	}
+
 	switch asset.uri.Scheme {
 	case UriIPFS:
 		if err := manager.RunIPFSTraitGetter(trait, asset); err != nil {
@@ -97,6 +215,14 @@ func (manager *Manager) UpdateAttributes(asset *Asset) (*Trait, error) {
 		if err := manager.RunHttpTraitGetter(trait, asset); err != nil {
 			return nil, err
 		}
+	case UriArweave:
+		if err := manager.RunArweaveTraitGetter(trait, asset); err != nil {
+			return nil, err
+		}
+	case UriToken:
+		if err := manager.RunTokenTraitGetter(trait, asset); err != nil {
+			return nil, err
+		}
 	default:
 		return nil, errURIFormatNotFound
 	}
@@ -105,54 +231,306 @@ func (manager *Manager) UpdateAttributes(asset *Asset) (*Trait, error) {
 	return trait, nil
 }
 
+// RunIPFSTraitGetter lists the directory behind the asset's base uri through
+// the IPFS node. Listing needs the node, so while it is down the tokens are
+// resolved one at a time through tokenURI, and read from the gateways.
 func (manager *Manager) RunIPFSTraitGetter(trait *Trait, asset *Asset) error {
-	ipfsUris, err := manager.Connection.IPFS.Client.ObjectGet(asset.uri.Host)
+	directory := joinPath(asset.uri.Host, strings.Trim(asset.uri.Path, "/"))
+	ipfsUris, err := manager.Connection.IPFS.Client.ObjectGet(directory)
+	if err != nil {
+		if len(manager.Connection.IPFS.Gateways) == 0 {
+			return errClientIPFSGet
+		}
+		log.Printf("[WARN]: IPFS node couldn't list %s, resolving tokens through tokenURI %s", directory, err)
+		return manager.RunTokenTraitGetter(trait, asset)
+	}
+
+	names := make([]string, 0, len(ipfsUris.Links))
+	hashes := make(map[string]string, len(ipfsUris.Links))
+	for _, link := range ipfsUris.Links {
+		names = append(names, link.Name)
+		hashes[link.Name] = link.Hash
+	}
+	ids, names := tokenPaths(names)
+
+	return manager.crawl(trait, asset, len(ids), func(i int) (*Token, error) {
+		token := Token{Id: ids[i]}
+		err := GetTokenData(manager.Connection.IPFS, hashes[names[i]], &token)
+		return &token, err
+	})
+}
+
+// RunHttpTraitGetter reads every token from the base uri followed by its id.
+// The ids are listed like those of tokenURI collections: over the known
+// token range, through tokenByIndex, or from whichever of zero or one
+// resolves.
+func (manager *Manager) RunHttpTraitGetter(trait *Trait, asset *Asset) error {
+	ethereum, err := manager.Connection.Chain(asset.chainId)
+	if err != nil {
+		return err
+	}
+
+	collection, err := NewCollection(asset.address, ethereum.Client)
+	if err != nil {
+		return errCreatingCollectionEthBinding
+	}
+
+	enumerator, err := NewTokenEnumerator(collection, asset)
 	if err != nil {
 		return err
 	}
 
-	for i := 0; i < len(ipfsUris.Links); i += 5000 {
-		var token Token
-		err := GetTokenData(manager.Connection.IPFS, ipfsUris.Links[i].Hash, &token)
+	return manager.crawl(trait, asset, enumerator.Len(), func(i int) (*Token, error) {
+		id, err := enumerator.TokenId(i)
 		if err != nil {
-			return err
+			return nil, err
+		}
+
+		token := Token{Id: id.String()}
+		err = GetTokenData(manager.Connection.Http, common.BuildUrl(asset.uri.Host, id.String()), &token)
+		return &token, err
+	})
+}
+
+// RunArweaveTraitGetter walks the path manifest behind the asset's base uri,
+// reading only the token files found under the uri's prefix.
+func (manager *Manager) RunArweaveTraitGetter(trait *Trait, asset *Asset) error {
+	manifest, err := manager.Connection.Arweave.Manifest(asset.uri.Host)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(manifest.Paths))
+	for path := range manifest.Paths {
+		if strings.HasPrefix(path, asset.uri.Path) {
+			names = append(names, strings.TrimPrefix(path, asset.uri.Path))
 		}
-		BuildTrait(&token.Attributes, trait)
 	}
+	ids, names := tokenPaths(names)
 
-	return nil
+	return manager.crawl(trait, asset, len(ids), func(i int) (*Token, error) {
+		token := Token{Id: ids[i]}
+		err := GetTokenData(manager.Connection.Arweave, manifest.Paths[asset.uri.Path+names[i]].Id, &token)
+		return &token, err
+	})
 }
 
-// todo: fix http getter
-func (manager *Manager) RunHttpTraitGetter(trait *Trait, asset *Asset) error {
-	for i := 0; i < int((asset.totalSupply).Int64()); i += 5000 {
-		var token Token
-		err := GetTokenData(manager.Connection.Http, common.BuildUrl(asset.uri.Host, i), &token)
+// RunTokenTraitGetter resolves each token's uri through tokenURI, for
+// collections without a base uri. Every uri is routed to its own fetcher, so
+// tokens may live on different hosts or schemes.
+func (manager *Manager) RunTokenTraitGetter(trait *Trait, asset *Asset) error {
+	ethereum, err := manager.Connection.Chain(asset.chainId)
+	if err != nil {
+		return err
+	}
+
+	collection, err := NewCollection(asset.address, ethereum.Client)
+	if err != nil {
+		return errCreatingCollectionEthBinding
+	}
+
+	enumerator, err := NewTokenEnumerator(collection, asset)
+	if err != nil {
+		return err
+	}
+
+	return manager.crawl(trait, asset, enumerator.Len(), func(i int) (*Token, error) {
+		id, uri, err := enumerator.TokenURI(i)
+		if err != nil {
+			return nil, err
+		}
+
+		fetcher, location, err := manager.Connection.Route(uri)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		BuildTrait(&token.Attributes, trait)
+
+		token := Token{Id: id.String()}
+		err = GetTokenData(fetcher, location, &token)
+		return &token, err
+	})
+}
+
+// RunERC1155TraitGetter discovers the token ids of an ERC-1155 collection
+// from its transfer logs and reads each one through uri(id). Every token is
+// weighted by its circulating supply.
+func (manager *Manager) RunERC1155TraitGetter(trait *Trait, asset *Asset) error {
+	if err := manager.Supply.Backfill(context.Background(), asset); err != nil {
+		return err
+	}
+
+	supplies, err := LoadSupplies(manager.Store, asset.Id())
+	if err != nil {
+		return err
+	}
+
+	ethereum, err := manager.Connection.Chain(asset.chainId)
+	if err != nil {
+		return err
+	}
+
+	collection, err := NewCollection1155(asset.address, ethereum.Client)
+	if err != nil {
+		return errCreatingCollectionEthBinding
+	}
+
+	units := new(big.Int)
+	for _, supply := range supplies {
+		units.Add(units, supply.Supply)
+	}
+	asset.SetTotalSupply(*units)
+
+	if asset.uri == nil {
+		asset.uri = &Uri{Scheme: UriToken}
 	}
+
+	return manager.crawl(trait, asset, len(supplies), func(i int) (*Token, error) {
+		id := supplies[i].Id
+
+		uri, err := collection.Uri(&bind.CallOpts{}, id)
+		if err != nil {
+			return nil, errTokenUriNotExist
+		}
+
+		fetcher, location, err := manager.Connection.Route(ExpandTokenUri(uri, id))
+		if err != nil {
+			return nil, err
+		}
+
+		token := Token{Id: id.String(), Weight: supplyWeight(supplies[i].Supply)}
+		err = GetTokenData(fetcher, location, &token)
+		return &token, err
+	})
+}
+
+// crawl runs the crawl with the concurrency of the asset's uri scheme and
+// keeps the tokens read on the asset.
+func (manager *Manager) crawl(trait *Trait, asset *Asset, total int, fetch TokenFetch) error {
+	workers := manager.Crawl.Workers(asset.uri.Scheme)
+
+	tokens, err := Crawl(trait, total, manager.Crawl.Stride, workers, fetch)
+	if err != nil {
+		return err
+	}
+	asset.tokens = tokens
 	return nil
 }
 
+// tokenIdFromPath reads the token id from a metadata file name, "42" or
+// "42.json". Any other file, such as index.html, an image or a folder, isn't
+// a token.
+func tokenIdFromPath(name string) (string, bool) {
+	name = strings.TrimSuffix(strings.TrimPrefix(name, "/"), ".json")
+	id, ok := new(big.Int).SetString(name, 10)
+	if !ok || id.Sign() < 0 || strings.HasPrefix(name, "+") {
+		return "", false
+	}
+	return id.String(), true
+}
+
+// tokenPaths keeps the token files among the names of a directory, sorted,
+// along with their ids. An id found twice, as "42" and "42.json", is read
+// from the first name only.
+func tokenPaths(names []string) ([]string, []string) {
+	sort.Strings(names)
+
+	ids := make([]string, 0, len(names))
+	paths := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		id, ok := tokenIdFromPath(name)
+		if !ok || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+		paths = append(paths, name)
+	}
+	return ids, paths
+}
+
+// supplyWeight is the weight of a token of the given supply. Supplies past
+// the float64 precision are rounded, never capped.
+func supplyWeight(supply *big.Int) float64 {
+	if supply.Sign() < 0 {
+		return 0
+	}
+	weight, _ := new(big.Float).SetInt(supply).Float64()
+	return weight
+}
+
+func (token *Token) weight() float64 {
+	if token.Weight < 1 {
+		return 1
+	}
+	return token.Weight
+}
+
 func GetTokenData(fetcher ClientFetcher, tokenUrl string, token *Token) error {
 	res, err := fetcher.Get(tokenUrl)
 	if err != nil {
 		return err
 	}
-	if err := common.UnmarshalJSON(res, &token); err != nil {
+	if err := common.UnmarshalJSON(res, token); err != nil {
 		return err
 	}
 	return nil
 }
 
+// reschedule queues a sequenced asset again after the interval of its
+// refresh policy.
+func (manager *Manager) reschedule(asset *Asset) {
+	if !manager.Waitlist.Requeue(asset, asset.lastSuccess.Add(asset.policy.Interval)) {
+		return
+	}
+	if err := SaveWaitlistEntry(manager.Store, asset); err != nil {
+		log.Print("[ERROR]: Issue storing waitlist entry", err)
+	}
+}
+
+// retry queues a failed asset again after the backoff of the retry policy,
+// or moves it to the dead-letter list once the policy gives up on it. An
+// asset untracked or paused during its run is left as the operator set it.
+func (manager *Manager) retry(asset *Asset, err error) {
+	if manager.Retry.Exhausted(asset.attempts, err) {
+		if manager.Waitlist.Dropped(asset.Id()) {
+			manager.Waitlist.Release(asset)
+			return
+		}
+		log.Printf("[DEADLETTER]: %s after %d attempts, %s\n", asset.Id(), asset.attempts, ErrorKind(err))
+		if err := deadLetter(manager.Store, asset, err); err != nil {
+			log.Print("[ERROR]: Issue storing dead letter", err)
+		}
+		manager.Waitlist.Release(asset)
+		return
+	}
+
+	notBefore := time.Now().Add(manager.Retry.Delay(asset.attempts))
+	if !manager.Waitlist.Requeue(asset, notBefore) {
+		return
+	}
+	if err := SaveWaitlistEntry(manager.Store, asset); err != nil {
+		log.Print("[ERROR]: Issue storing waitlist entry", err)
+	}
+}
+
+// WaitlistAppend queues the asset to run now and stores its entry, so it
+// stays tracked across restarts. Assets already queued or running are left
+// as they are.
 func (manager *Manager) WaitlistAppend(asset *Asset) {
-	manager.Waitlist.PriorityQueuePush(asset)
+	if !manager.Waitlist.Push(asset, time.Now()) {
+		return
+	}
+	if err := SaveWaitlistEntry(manager.Store, asset); err != nil {
+		log.Print("[ERROR]: Issue storing waitlist entry", err)
+	}
 }
 
+// WaitlistRemove leases the asset due first. Its stored entry is only
+// rescheduled once the run ends, so an interrupted run is picked up after a
+// restart.
 func (manager *Manager) WaitlistRemove() (*Asset, error) {
-	asset, err := manager.Waitlist.PriorityQueueRemove()
+	asset, err := manager.Waitlist.Lease(time.Now())
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,294 @@
+package collection
+
+import (
+	"errors"
+	"time"
+
+	"github.com/levelabs/level-go/store"
+)
+
+var (
+	ErrAssetNotTracked = errors.New("Collection isn't tracked")
+	ErrAssetRunning    = errors.New("Collection is being sequenced")
+	ErrAssetPaused     = errors.New("Collection is paused")
+)
+
+// States of a tracked collection. Idle collections are stored but were
+// added while the manager ran, and are only queued on its next start.
+const (
+	StateQueued  = "queued"
+	StateRunning = "running"
+	StatePaused  = "paused"
+	StateDead    = "dead"
+	StateIdle    = "idle"
+)
+
+// JobStatus reports where a tracked collection stands and how its last run
+// went.
+type JobStatus struct {
+	Collection string        `json:"collection"`
+	State      string        `json:"state"`
+	Policy     RefreshPolicy `json:"policy"`
+	NextRun    *time.Time    `json:"next_run,omitempty"`
+	Attempts   int           `json:"attempts"`
+
+	LastRun    *time.Time `json:"last_run,omitempty"`
+	Duration   string     `json:"duration,omitempty"`
+	Tokens     int        `json:"tokens"`
+	LastResult string     `json:"last_result,omitempty"`
+	LastError  string     `json:"last_error,omitempty"`
+}
+
+func newJobStatus(entry *WaitlistEntry, state string, next *time.Time) *JobStatus {
+	status := JobStatus{
+		Collection: entry.Collection,
+		State:      state,
+		Policy:     entry.Policy,
+		Attempts:   entry.Attempts,
+		Tokens:     entry.LastTokens,
+		LastResult: entry.LastResult,
+		LastError:  entry.LastError,
+		NextRun:    next,
+	}
+	if !entry.LastRun.IsZero() {
+		status.LastRun = &entry.LastRun
+		status.Duration = entry.LastDuration.String()
+	}
+	return &status
+}
+
+func newDeadStatus(letter *DeadLetter) *JobStatus {
+	return &JobStatus{
+		Collection: letter.Collection,
+		State:      StateDead,
+		Policy:     letter.Policy,
+		Attempts:   letter.Attempts,
+		LastRun:    &letter.DeadAt,
+		LastResult: ResultFailed,
+		LastError:  letter.Error,
+	}
+}
+
+// Track queues a collection with policy, to run no earlier than notBefore,
+// replacing the policy of a collection tracked already. Paused and
+// dead-lettered collections are tracked again. Collections on a chain
+// without a client are refused.
+func (manager *Manager) Track(assetId string, policy RefreshPolicy, notBefore time.Time) (*JobStatus, error) {
+	chainId, address, err := ParseAssetId(assetId)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := manager.Connection.Chain(chainId); err != nil {
+		return nil, err
+	}
+	id := AssetId(chainId, address)
+
+	entry, err := manager.loadEntry(id)
+	if err == ErrAssetNotTracked {
+		entry = &WaitlistEntry{Collection: id}
+	} else if err != nil {
+		return nil, err
+	}
+
+	asset, err := entry.Asset(manager.Store)
+	if err != nil {
+		return nil, err
+	}
+	asset.policy = policy
+	asset.paused = false
+
+	if err := manager.Waitlist.Replace(asset, notBefore); err != nil {
+		return nil, err
+	}
+	if err := SaveWaitlistEntry(manager.Store, asset); err != nil {
+		return nil, err
+	}
+	if err := manager.Store.Delete(store.DeadLetterKey(id)); err != nil {
+		return nil, err
+	}
+	return manager.Status(id)
+}
+
+// Requeue takes a collection off the dead-letter list and queues it to run
+// now, with its failures forgotten. It keeps the refresh policy it died with,
+// and stays off the queue if it was paused.
+func (manager *Manager) Requeue(assetId string) (*JobStatus, error) {
+	entry, err := RequeueAsset(manager.Store, assetId)
+	if err != nil {
+		return nil, err
+	}
+
+	asset, err := entry.Asset(manager.Store)
+	if err != nil {
+		return nil, err
+	}
+	if !asset.paused {
+		manager.Waitlist.Push(asset, time.Now())
+	}
+	return manager.Status(entry.Collection)
+}
+
+// Untrack stops tracking a collection, dropping it once its run ends if it
+// is running.
+func (manager *Manager) Untrack(assetId string) error {
+	id, err := NormalizeAssetId(assetId)
+	if err != nil {
+		return err
+	}
+
+	removed := manager.Waitlist.Remove(id)
+	if _, err := manager.loadEntry(id); err == ErrAssetNotTracked {
+		if _, err := LoadDeadLetter(manager.Store, id); err == store.ErrNotFound && !removed {
+			return ErrAssetNotTracked
+		}
+	} else if err != nil {
+		return err
+	}
+
+	return forgetAsset(manager.Store, id)
+}
+
+// Pause takes a collection off the queue while keeping it tracked.
+func (manager *Manager) Pause(assetId string) (*JobStatus, error) {
+	id, err := NormalizeAssetId(assetId)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := manager.loadEntry(id)
+	if err != nil {
+		return nil, err
+	}
+
+	manager.Waitlist.Remove(id)
+	entry.Paused = true
+	if err := manager.Store.Set(store.WaitlistKey(id), entry); err != nil {
+		return nil, err
+	}
+	return manager.Status(id)
+}
+
+// Resume queues a paused collection to run now.
+func (manager *Manager) Resume(assetId string) (*JobStatus, error) {
+	id, err := NormalizeAssetId(assetId)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := manager.loadEntry(id)
+	if err != nil {
+		return nil, err
+	}
+	if !entry.Paused {
+		return manager.Status(id)
+	}
+
+	asset, err := entry.Asset(manager.Store)
+	if err != nil {
+		return nil, err
+	}
+	asset.paused = false
+
+	if err := manager.Waitlist.Replace(asset, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := SaveWaitlistEntry(manager.Store, asset); err != nil {
+		return nil, err
+	}
+	return manager.Status(id)
+}
+
+// Resequence makes a queued collection due right away, ahead of its refresh
+// interval and class.
+func (manager *Manager) Resequence(assetId string) (*JobStatus, error) {
+	id, err := NormalizeAssetId(assetId)
+	if err != nil {
+		return nil, err
+	}
+
+	err = manager.Waitlist.Reprioritize(id, time.Now())
+	if err == ErrAssetNotTracked {
+		if entry, loadErr := manager.loadEntry(id); loadErr == nil && entry.Paused {
+			return nil, ErrAssetPaused
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return manager.Status(id)
+}
+
+// Status reports on a tracked or dead-lettered collection.
+func (manager *Manager) Status(assetId string) (*JobStatus, error) {
+	id, err := NormalizeAssetId(assetId)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := manager.loadEntry(id)
+	if err == ErrAssetNotTracked {
+		letter, err := LoadDeadLetter(manager.Store, id)
+		if err == store.ErrNotFound {
+			return nil, ErrAssetNotTracked
+		}
+		if err != nil {
+			return nil, err
+		}
+		return newDeadStatus(letter), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return manager.status(entry), nil
+}
+
+// Statuses reports on every tracked and dead-lettered collection.
+func (manager *Manager) Statuses() ([]*JobStatus, error) {
+	entries, err := LoadWaitlist(manager.Store)
+	if err != nil {
+		return nil, err
+	}
+	letters, err := LoadDeadLetters(manager.Store)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]*JobStatus, 0, len(entries)+len(letters))
+	for _, entry := range entries {
+		statuses = append(statuses, manager.status(entry))
+	}
+	for _, letter := range letters {
+		statuses = append(statuses, newDeadStatus(letter))
+	}
+	return statuses, nil
+}
+
+// status reports on a tracked collection, reading its state and next run
+// from the waitlist.
+func (manager *Manager) status(entry *WaitlistEntry) *JobStatus {
+	state := manager.Waitlist.State(entry.Collection)
+	if state == "" {
+		state = StateIdle
+		if entry.Paused {
+			state = StatePaused
+		}
+	}
+
+	var next *time.Time
+	if due, ok := manager.Waitlist.Due(entry.Collection); ok {
+		next = &due
+	}
+	return newJobStatus(entry, state, next)
+}
+
+func (manager *Manager) loadEntry(assetId string) (*WaitlistEntry, error) {
+	var entry WaitlistEntry
+	err := manager.Store.Get(store.WaitlistKey(assetId), &entry)
+	if err == store.ErrNotFound {
+		return nil, ErrAssetNotTracked
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
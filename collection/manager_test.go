@@ -0,0 +1,51 @@
+package collection
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestSupplyWeight(t *testing.T) {
+	huge, _ := new(big.Int).SetString("340282366920938463463374607431768211456", 10)
+	tests := []struct {
+		supply *big.Int
+		weight float64
+	}{
+		{big.NewInt(-1), 0},
+		{big.NewInt(0), 0},
+		{big.NewInt(1), 1},
+		{big.NewInt(10000), 10000},
+		{big.NewInt(math.MaxInt32 + 1), math.MaxInt32 + 1},
+		{new(big.Int).Lsh(big.NewInt(1), 63), math.Pow(2, 63)},
+		{huge, math.Pow(2, 128)},
+	}
+
+	for _, test := range tests {
+		if weight := supplyWeight(test.supply); weight != test.weight {
+			t.Errorf("supplyWeight(%s) = %g, want %g", test.supply, weight, test.weight)
+		}
+	}
+}
+
+func TestWeightedFrequency(t *testing.T) {
+	// two fungible ids far past the int32 range keep their 3 to 1 ratio
+	supplies := []*big.Int{
+		new(big.Int).Mul(big.NewInt(3), new(big.Int).Lsh(big.NewInt(1), 40)),
+		new(big.Int).Lsh(big.NewInt(1), 40),
+	}
+	values := []string{"Common", "Rare"}
+
+	trait := NewTrait()
+	for i, supply := range supplies {
+		attributes := []Attribute{{Trait: "Kind", Value: StringValue(values[i])}}
+		BuildWeightedTrait(&attributes, trait, supplyWeight(supply))
+	}
+
+	if f := trait.Frequency("Kind", "Common"); f != 0.75 {
+		t.Errorf("Frequency(Common) = %g, want 0.75", f)
+	}
+	if f := trait.Frequency("Kind", "Rare"); f != 0.25 {
+		t.Errorf("Frequency(Rare) = %g, want 0.25", f)
+	}
+}
@@ -0,0 +1,192 @@
+package collection
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+)
+
+var (
+	errAttributesFormat = errors.New("Attributes are neither a list nor an object")
+)
+
+// TraitUntyped is the category of the attributes published without one.
+const TraitUntyped = "Property"
+
+// traitKeys are the keys naming the category of an attribute, by preference.
+var traitKeys = []string{"trait_type", "type", "trait", "name", "key"}
+
+// Metadata is the OpenSea metadata of a token. Properties holds Enjin style
+// properties as written, and Extra every field outside of the standard as a
+// raw JSON object, so nothing read is lost.
+type Metadata struct {
+	Name            string          `json:"name,omitempty"`
+	Description     string          `json:"description,omitempty"`
+	Image           string          `json:"image"`
+	ImageData       string          `json:"image_data,omitempty"`
+	ExternalUrl     string          `json:"external_url,omitempty"`
+	AnimationUrl    string          `json:"animation_url,omitempty"`
+	BackgroundColor string          `json:"background_color,omitempty"`
+	YoutubeUrl      string          `json:"youtube_url,omitempty"`
+	Attributes      []Attribute     `json:"attributes"`
+	Properties      json.RawMessage `json:"properties,omitempty"`
+	Extra           json.RawMessage `json:"extra,omitempty"`
+}
+
+// metadataStrings locates the standard text fields of the metadata.
+var metadataStrings = map[string]func(m *Metadata) *string{
+	"name":             func(m *Metadata) *string { return &m.Name },
+	"description":      func(m *Metadata) *string { return &m.Description },
+	"image":            func(m *Metadata) *string { return &m.Image },
+	"image_data":       func(m *Metadata) *string { return &m.ImageData },
+	"external_url":     func(m *Metadata) *string { return &m.ExternalUrl },
+	"animation_url":    func(m *Metadata) *string { return &m.AnimationUrl },
+	"background_color": func(m *Metadata) *string { return &m.BackgroundColor },
+	"youtube_url":      func(m *Metadata) *string { return &m.YoutubeUrl },
+}
+
+// ParseMetadata reads a metadata document. Text fields written with another
+// JSON type, such as a numeric background color, are kept as their JSON text.
+func ParseMetadata(data []byte) (*Metadata, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	var metadata Metadata
+	for key, field := range metadataStrings {
+		if raw, ok := fields[key]; ok {
+			*field(&metadata) = rawString(raw)
+			delete(fields, key)
+		}
+	}
+
+	if raw, ok := fields["attributes"]; ok {
+		attributes, err := ParseAttributes(raw)
+		if err != nil {
+			return nil, err
+		}
+		metadata.Attributes = attributes
+		delete(fields, "attributes")
+	}
+	if raw, ok := fields["properties"]; ok {
+		metadata.Properties = raw
+		delete(fields, "properties")
+	}
+
+	if len(fields) > 0 {
+		extra, err := json.Marshal(fields)
+		if err != nil {
+			return nil, err
+		}
+		metadata.Extra = extra
+	}
+	return &metadata, nil
+}
+
+func rawString(raw json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+	if string(raw) == "null" {
+		return ""
+	}
+	return string(raw)
+}
+
+// ParseAttributes normalizes the shapes attributes are published in into a
+// list: the OpenSea list, lists of entries naming their category under
+// another key or not at all, lists of bare values, and objects mapping each
+// category to its value or to an attribute entry.
+func ParseAttributes(raw json.RawMessage) ([]Attribute, error) {
+	var list []json.RawMessage
+	if err := json.Unmarshal(raw, &list); err == nil {
+		attributes := make([]Attribute, 0, len(list))
+		for _, entry := range list {
+			attribute, ok, err := parseAttribute(entry, TraitUntyped)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				attributes = append(attributes, attribute)
+			}
+		}
+		return attributes, nil
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &object); err == nil {
+		categories := make([]string, 0, len(object))
+		for category := range object {
+			categories = append(categories, category)
+		}
+		sort.Strings(categories)
+
+		attributes := make([]Attribute, 0, len(object))
+		for _, category := range categories {
+			attribute, ok, err := parseAttribute(object[category], category)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				attributes = append(attributes, attribute)
+			}
+		}
+		return attributes, nil
+	}
+
+	if string(raw) == "null" {
+		return nil, nil
+	}
+	return nil, errAttributesFormat
+}
+
+// parseAttribute reads an attribute entry, or a bare value, falling back to
+// category when the entry doesn't name its own. An entry without a value
+// holds the trait it names as missing, and is skipped when it names none.
+func parseAttribute(raw json.RawMessage, category string) (Attribute, bool, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		var value AttributeValue
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return Attribute{}, false, err
+		}
+		return Attribute{Trait: category, Value: value}, true, nil
+	}
+
+	attribute := Attribute{Trait: category}
+	named := false
+	for _, key := range traitKeys {
+		if name, ok := fields[key]; ok {
+			if trait := rawString(name); trait != "" {
+				attribute.Trait = trait
+				named = true
+				break
+			}
+		}
+	}
+
+	value, ok := fields["value"]
+	if !ok || string(value) == "null" {
+		if !named && category == TraitUntyped {
+			return Attribute{}, false, nil
+		}
+		attribute.Value = StringValue(TraitMissing)
+		return attribute, true, nil
+	}
+	if err := json.Unmarshal(value, &attribute.Value); err != nil {
+		return Attribute{}, false, err
+	}
+	if raw, ok := fields["display_type"]; ok {
+		attribute.DisplayType = rawString(raw)
+	}
+	if raw, ok := fields["max_value"]; ok {
+		var maxValue AttributeValue
+		if err := json.Unmarshal(raw, &maxValue); err != nil {
+			return Attribute{}, false, err
+		}
+		attribute.MaxValue = &maxValue
+	}
+	return attribute, true, nil
+}
@@ -0,0 +1,142 @@
+package collection
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAttributes(t *testing.T) {
+	level := Attribute{Trait: "Level", Value: NumberValue(3), DisplayType: DisplayNumber}
+	tests := []struct {
+		name       string
+		raw        string
+		attributes []Attribute
+	}{
+		{
+			"opensea list",
+			`[{"trait_type":"Fur","value":"Gold"},{"trait_type":"Level","value":3,"display_type":"number"}]`,
+			[]Attribute{{Trait: "Fur", Value: StringValue("Gold")}, level},
+		},
+		{
+			"alternate trait keys",
+			`[{"type":"Eyes","value":"Red"},{"name":"Hat","value":"Cap"},{"key":"Mouth","value":"Grin"}]`,
+			[]Attribute{
+				{Trait: "Eyes", Value: StringValue("Red")},
+				{Trait: "Hat", Value: StringValue("Cap")},
+				{Trait: "Mouth", Value: StringValue("Grin")},
+			},
+		},
+		{
+			"untyped entries and bare values",
+			`[{"value":"Gold"},"Shiny",7]`,
+			[]Attribute{
+				{Trait: TraitUntyped, Value: StringValue("Gold")},
+				{Trait: TraitUntyped, Value: StringValue("Shiny")},
+				{Trait: TraitUntyped, Value: NumberValue(7)},
+			},
+		},
+		{
+			"entry without a value",
+			`[{"trait_type":"Hat"},{"trait_type":"Fur","value":null},{},null]`,
+			[]Attribute{
+				{Trait: "Hat", Value: StringValue(TraitMissing)},
+				{Trait: "Fur", Value: StringValue(TraitMissing)},
+			},
+		},
+		{
+			"object of categories",
+			`{"Fur":"Gold","Eyes":"Blue","Level":{"value":3,"display_type":"number"},"Hat":{}}`,
+			[]Attribute{
+				{Trait: "Eyes", Value: StringValue("Blue")},
+				{Trait: "Fur", Value: StringValue("Gold")},
+				{Trait: "Hat", Value: StringValue(TraitMissing)},
+				level,
+			},
+		},
+		{"null", `null`, nil},
+	}
+
+	for _, test := range tests {
+		attributes, err := ParseAttributes([]byte(test.raw))
+		if err != nil {
+			t.Errorf("%s: ParseAttributes = %v", test.name, err)
+			continue
+		}
+		if len(attributes) == 0 && len(test.attributes) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(attributes, test.attributes) {
+			t.Errorf("%s: ParseAttributes = %+v, want %+v", test.name, attributes, test.attributes)
+		}
+	}
+
+	if _, err := ParseAttributes([]byte(`"Gold"`)); err != errAttributesFormat {
+		t.Errorf("ParseAttributes of a string = %v, want %v", err, errAttributesFormat)
+	}
+}
+
+func TestParseMetadata(t *testing.T) {
+	metadata, err := ParseMetadata([]byte(`{
+		"name": "Ape #1",
+		"image": "ipfs://QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG",
+		"background_color": 123456,
+		"attributes": {"Fur": "Gold"},
+		"properties": {"rarity": "rare"},
+		"dna": "abc"
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if metadata.Name != "Ape #1" || metadata.BackgroundColor != "123456" {
+		t.Errorf("text fields = %q %q, want Ape #1 and 123456", metadata.Name, metadata.BackgroundColor)
+	}
+	if len(metadata.Attributes) != 1 || metadata.Attributes[0].Trait != "Fur" {
+		t.Errorf("attributes = %+v, want Fur", metadata.Attributes)
+	}
+	if string(metadata.Properties) != `{"rarity": "rare"}` {
+		t.Errorf("properties = %s", metadata.Properties)
+	}
+	if string(metadata.Extra) != `{"dna":"abc"}` {
+		t.Errorf("extra = %s, want the unknown fields", metadata.Extra)
+	}
+}
+
+func TestTraitFinalize(t *testing.T) {
+	documents := []string{
+		`{"attributes":[{"trait_type":"Fur","value":"Gold"},{"trait_type":"Level","value":3,"display_type":"number"}]}`,
+		`{"attributes":[{"trait_type":"Fur","value":"Blue"}]}`,
+		`{"attributes":[]}`,
+	}
+
+	trait := NewTrait()
+	tokens := make([]*Token, len(documents))
+	for i, document := range documents {
+		var token Token
+		if err := token.UnmarshalJSON([]byte(document)); err != nil {
+			t.Fatal(err)
+		}
+		BuildTrait(&token.Attributes, trait)
+		tokens[i] = &token
+	}
+	trait.Finalize(tokens)
+
+	if missing := trait.Values("Fur")[TraitMissing]; missing != 1 {
+		t.Errorf("Fur missing = %g, want 1", missing)
+	}
+	if _, ok := trait.Counter["Level"]; ok {
+		t.Error("numeric trait Level is counted as a categorical one")
+	}
+	if counts := trait.Values(TraitCount); counts["2"] != 1 || counts["1"] != 1 || counts["0"] != 1 {
+		t.Errorf("trait counts = %v, want one token of 0, 1 and 2 traits", counts)
+	}
+
+	completed := trait.Complete(tokens[2].Attributes)
+	want := []Attribute{
+		{Trait: "Fur", Value: StringValue(TraitMissing)},
+		{Trait: TraitCount, Value: StringValue("0")},
+	}
+	if !reflect.DeepEqual(completed, want) {
+		t.Errorf("Complete = %+v, want %+v", completed, want)
+	}
+}
@@ -0,0 +1,352 @@
+package collection
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+
+	"github.com/levelabs/level-go/store"
+)
+
+var (
+	errContractNotDeployed = errors.New("Couldn't find code at the collection assetId")
+	errChunkTooSmall       = errors.New("Transfer logs couldn't be read at the minimum block range")
+	errHistoryUnavailable  = errors.New("Node doesn't serve the historical state locating the deployment block")
+)
+
+const (
+	defaultMinChunk = 16
+	defaultMaxChunk = 100000
+	initialChunk    = 2000
+)
+
+// Checkpoint records how far the Transfer logs of a collection were replayed.
+// Block is the last block applied, or zero before the first chunk, and
+// Confirmed the last one deep enough to be final. The blocks in between are
+// journaled.
+type Checkpoint struct {
+	Deployed  uint64 `json:"deployed"`
+	Block     uint64 `json:"block"`
+	Confirmed uint64 `json:"confirmed"`
+}
+
+// OwnershipIndexer replays the Transfer logs of a collection into an index
+// of token to owner and owner to tokens.
+type OwnershipIndexer struct {
+	client *Client
+	store  *store.Store
+
+	// Chunks bound the block range of a single log query. The range halves
+	// when a query fails and doubles when it succeeds.
+	MinChunk uint64
+	MaxChunk uint64
+
+	// DeployBlocks are the configured deployment blocks by asset id, used
+	// when the node can't locate them.
+	DeployBlocks map[string]uint64
+}
+
+// NewOwnershipIndexer builds an indexer reading each collection from the
+// client of its chain.
+func NewOwnershipIndexer(client *Client, s *store.Store) *OwnershipIndexer {
+	return &OwnershipIndexer{
+		client:   client,
+		store:    s,
+		MinChunk: defaultMinChunk,
+		MaxChunk: defaultMaxChunk,
+	}
+}
+
+// Backfill replays Transfer logs from the last checkpoint, or from the
+// deployment block on the first run, up to the chain head, and returns the
+// checkpoint reached.
+func (indexer *OwnershipIndexer) Backfill(ctx context.Context, asset *Asset) (*Checkpoint, error) {
+	assetId := asset.Id()
+
+	ethereum, err := indexer.client.Chain(asset.chainId)
+	if err != nil {
+		return nil, err
+	}
+
+	filterer, err := NewCollectionFilterer(asset.address, ethereum.Client)
+	if err != nil {
+		return nil, errCreatingCollectionEthBinding
+	}
+
+	head, err := ethereum.Client.BlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoint, err := loadCheckpoint(ctx, indexer.store, store.CheckpointKey(assetId), ethereum.Client, asset, head, indexer.DeployBlocks[assetId])
+	if err != nil {
+		return nil, err
+	}
+
+	if err := indexer.Reconcile(ctx, ethereum, assetId, checkpoint); err != nil {
+		return nil, err
+	}
+
+	from := checkpoint.Block + 1
+	if checkpoint.Block < checkpoint.Deployed {
+		from = checkpoint.Deployed
+	}
+
+	chunk := uint64(initialChunk)
+	for from <= head {
+		to := from + chunk - 1
+		if to > head {
+			to = head
+		}
+
+		transfers, err := indexer.filterTransfers(ctx, filterer, from, to)
+		if err != nil {
+			if chunk <= indexer.MinChunk {
+				return nil, errChunkTooSmall
+			}
+			chunk /= 2
+			continue
+		}
+
+		journals, err := indexer.journals(ctx, ethereum, transfers, from, to, head)
+		if err != nil {
+			return nil, err
+		}
+
+		checkpoint.Block = to
+		checkpoint.Confirmed = confirmedBelow(head, confirmations(ethereum))
+		if err := indexer.apply(assetId, transfers, journals, checkpoint); err != nil {
+			return nil, err
+		}
+		log.Printf("[OWNERS]: %s:%d-%d %d transfers\n", asset.Id(), from, to, len(transfers))
+
+		from = to + 1
+		if chunk < indexer.MaxChunk {
+			chunk *= 2
+		}
+	}
+	return checkpoint, nil
+}
+
+func (indexer *OwnershipIndexer) filterTransfers(
+	ctx context.Context,
+	filterer *CollectionFilterer,
+	from uint64,
+	to uint64,
+) ([]*CollectionTransfer, error) {
+	it, err := filterer.FilterTransfer(&bind.FilterOpts{Start: from, End: &to, Context: ctx}, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	var transfers []*CollectionTransfer
+	for it.Next() {
+		transfers = append(transfers, it.Event)
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+	return transfers, nil
+}
+
+// loadCheckpoint reads the checkpoint stored at key, locating the deployment
+// block when the collection has never been indexed. A node without the
+// historical state to locate it falls back to deployBlock, when configured.
+func loadCheckpoint(
+	ctx context.Context,
+	s *store.Store,
+	key []byte,
+	caller bind.ContractCaller,
+	asset *Asset,
+	head uint64,
+	deployBlock uint64,
+) (*Checkpoint, error) {
+	var checkpoint Checkpoint
+	err := s.Get(key, &checkpoint)
+	if err == nil {
+		return &checkpoint, nil
+	}
+	if err != store.ErrNotFound {
+		return nil, err
+	}
+
+	deployed, err := FindDeploymentBlock(ctx, caller, asset.address, head)
+	if err == errHistoryUnavailable {
+		if deployBlock == 0 {
+			log.Printf("[WARN]: %s deployment block unknown, the node isn't an archive node: set deploy_block for the collection", asset.Id())
+			return nil, err
+		}
+		log.Printf("[OWNERS]: %s starting from the configured deploy_block %d\n", asset.Id(), deployBlock)
+		deployed, err = deployBlock, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Checkpoint{Deployed: deployed}, nil
+}
+
+// apply writes the transfers, the journals of their unconfirmed blocks and
+// the checkpoint they lead to in one batch, dropping the journals which the
+// checkpoint has confirmed.
+func (indexer *OwnershipIndexer) apply(
+	assetId string,
+	transfers []*CollectionTransfer,
+	journals map[uint64]*BlockJournal,
+	checkpoint *Checkpoint,
+) error {
+	confirmed, err := confirmedJournals(indexer.store, assetId, checkpoint.Confirmed)
+	if err != nil {
+		return err
+	}
+
+	return indexer.store.WriteBatch(func(batch *store.Batch) error {
+		for _, transfer := range transfers {
+			if err := ApplyTransfer(batch, assetId, transfer); err != nil {
+				return err
+			}
+		}
+		for block, journal := range journals {
+			if err := batch.Set(store.JournalKey(assetId, block), journal); err != nil {
+				return err
+			}
+		}
+		for _, block := range confirmed {
+			if err := batch.Delete(store.JournalKey(assetId, block)); err != nil {
+				return err
+			}
+		}
+		return batch.Set(store.CheckpointKey(assetId), checkpoint)
+	})
+}
+
+// applyLive applies a single transfer received from a subscription. Removed
+// transfers roll the index back to the block before theirs, as does a
+// transfer whose block hash differs from the one journaled for its block.
+func (indexer *OwnershipIndexer) applyLive(
+	ethereum *Ethereum,
+	assetId string,
+	transfer *CollectionTransfer,
+	checkpoint *Checkpoint,
+) error {
+	block := transfer.Raw.BlockNumber
+	if transfer.Raw.Removed {
+		return indexer.Rollback(assetId, checkpoint, block-1)
+	}
+
+	var journal BlockJournal
+	err := indexer.store.Get(store.JournalKey(assetId, block), &journal)
+	if err != nil && err != store.ErrNotFound {
+		return err
+	}
+	if err == nil && journal.Hash != transfer.Raw.BlockHash.Hex() {
+		if err := indexer.Rollback(assetId, checkpoint, block-1); err != nil {
+			return err
+		}
+		err = store.ErrNotFound
+	}
+	if err == store.ErrNotFound {
+		journal = BlockJournal{Block: block, Hash: transfer.Raw.BlockHash.Hex()}
+	}
+	journal.Transfers = append(journal.Transfers, NewJournalTransfer(transfer))
+
+	// the block is only complete once a later one arrives, so the checkpoint
+	// stays behind it and the block is replayed whole after a disconnect
+	checkpoint.Block = block - 1
+	if confirmed := confirmedBelow(block, confirmations(ethereum)); confirmed > checkpoint.Confirmed {
+		checkpoint.Confirmed = confirmed
+	}
+
+	journals := map[uint64]*BlockJournal{block: &journal}
+	return indexer.apply(assetId, []*CollectionTransfer{transfer}, journals, checkpoint)
+}
+
+// ApplyTransfer moves a token from its sender to its receiver in the index.
+// Mints have no sender and burns no receiver.
+func ApplyTransfer(batch *store.Batch, assetId string, transfer *CollectionTransfer) error {
+	id := transfer.TokenId.String()
+	zero := ethcommon.Address{}
+
+	if transfer.From != zero {
+		if err := batch.Delete(store.HoldingKey(assetId, transfer.From.Hex(), id)); err != nil {
+			return err
+		}
+	}
+
+	if transfer.To == zero {
+		return batch.Delete(store.OwnerKey(assetId, id))
+	}
+	if err := batch.Set(store.OwnerKey(assetId, id), transfer.To.Hex()); err != nil {
+		return err
+	}
+	return batch.Set(store.HoldingKey(assetId, transfer.To.Hex(), id), true)
+}
+
+// FindDeploymentBlock searches for the first block holding code at address.
+// Reading the code at past blocks takes an archive node: when it fails the
+// cause is logged and errHistoryUnavailable returned.
+func FindDeploymentBlock(ctx context.Context, client bind.ContractCaller, address ethcommon.Address, head uint64) (uint64, error) {
+	code, err := client.CodeAt(ctx, address, new(big.Int).SetUint64(head))
+	if err != nil {
+		return 0, err
+	}
+	if len(code) == 0 {
+		return 0, errContractNotDeployed
+	}
+
+	low, high := uint64(0), head
+	for low < high {
+		mid := low + (high-low)/2
+		code, err := client.CodeAt(ctx, address, new(big.Int).SetUint64(mid))
+		if err != nil {
+			if ctx.Err() != nil {
+				return 0, ctx.Err()
+			}
+			log.Printf("[WARN]: Reading the code of %s at block %d %s", address.Hex(), mid, err)
+			return 0, errHistoryUnavailable
+		}
+		if len(code) > 0 {
+			high = mid
+		} else {
+			low = mid + 1
+		}
+	}
+	return low, nil
+}
+
+func LoadCheckpoint(s *store.Store, assetId string) (*Checkpoint, error) {
+	var checkpoint Checkpoint
+	if err := s.Get(store.CheckpointKey(assetId), &checkpoint); err != nil {
+		return nil, err
+	}
+	return &checkpoint, nil
+}
+
+// Owner returns the current owner of a token.
+func Owner(s *store.Store, assetId string, id string) (string, error) {
+	var owner string
+	if err := s.Get(store.OwnerKey(assetId, id), &owner); err != nil {
+		return "", err
+	}
+	return owner, nil
+}
+
+// TokensOf returns the ids of the tokens held by owner.
+func TokensOf(s *store.Store, assetId string, owner string) ([]string, error) {
+	prefix := store.HoldingPrefix(assetId, owner)
+
+	var ids []string
+	err := s.Iterate(prefix, func(key []byte, value []byte) error {
+		ids = append(ids, strings.TrimPrefix(string(key), string(prefix)))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
@@ -0,0 +1,139 @@
+package collection
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	errSubscriptionClosed = errors.New("Transfer subscription was closed")
+)
+
+const (
+	defaultPollInterval = 15 * time.Second
+	defaultRetryDelay   = 5 * time.Second
+)
+
+// OwnershipStream keeps the ownership index of a collection current by
+// subscribing to its Transfer logs over the websocket connection, and by
+// polling them when subscriptions aren't available.
+type OwnershipStream struct {
+	indexer *OwnershipIndexer
+	client  *Client
+
+	PollInterval time.Duration
+	RetryDelay   time.Duration
+}
+
+func NewOwnershipStream(client *Client, indexer *OwnershipIndexer) *OwnershipStream {
+	return &OwnershipStream{
+		indexer:      indexer,
+		client:       client,
+		PollInterval: defaultPollInterval,
+		RetryDelay:   defaultRetryDelay,
+	}
+}
+
+// Follow indexes the collection's transfers as they happen until ctx is
+// done. After a disconnect it subscribes again and replays the logs missed
+// since the last checkpoint. It gives up when the deployment block can't be
+// located, which no retry fixes.
+func (stream *OwnershipStream) Follow(ctx context.Context, asset *Asset) error {
+	for {
+		err := stream.watch(ctx, asset)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == rpc.ErrNotificationsUnsupported {
+			return stream.poll(ctx, asset)
+		}
+		if err == errHistoryUnavailable {
+			return err
+		}
+
+		log.Printf("[WARN]: Transfer subscription %s dropped %s", asset.Id(), err)
+		select {
+		case <-time.After(stream.RetryDelay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// watch subscribes first and backfills second, so no transfer falls between
+// the two. Transfers already covered by the backfill are skipped.
+func (stream *OwnershipStream) watch(ctx context.Context, asset *Asset) error {
+	ethereum, err := stream.client.Chain(asset.chainId)
+	if err != nil {
+		return err
+	}
+	if ethereum.Stream == nil {
+		return rpc.ErrNotificationsUnsupported
+	}
+
+	filterer, err := NewCollectionFilterer(asset.address, ethereum.Stream)
+	if err != nil {
+		return errCreatingCollectionEthBinding
+	}
+
+	sink := make(chan *CollectionTransfer, 64)
+	sub, err := filterer.WatchTransfer(&bind.WatchOpts{Context: ctx}, sink, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+
+	checkpoint, err := stream.indexer.Backfill(ctx, asset)
+	if err != nil {
+		return err
+	}
+
+	assetId := asset.Id()
+	for {
+		select {
+		case transfer := <-sink:
+			if transfer.Raw.BlockNumber <= checkpoint.Block && !transfer.Raw.Removed {
+				continue
+			}
+			if err := stream.indexer.applyLive(ethereum, assetId, transfer, checkpoint); err != nil {
+				return err
+			}
+		case err := <-sub.Err():
+			if err == nil {
+				return errSubscriptionClosed
+			}
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// poll replays the logs since the last checkpoint at every interval.
+func (stream *OwnershipStream) poll(ctx context.Context, asset *Asset) error {
+	log.Printf("[OWNERS]: %s polling every %s\n", asset.Id(), stream.PollInterval)
+
+	ticker := time.NewTicker(stream.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		_, err := stream.indexer.Backfill(ctx, asset)
+		if err == errHistoryUnavailable {
+			return err
+		}
+		if err != nil {
+			log.Printf("[WARN]: Polling transfers %s %s", asset.Id(), err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
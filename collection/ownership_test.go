@@ -0,0 +1,69 @@
+package collection
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+
+	"github.com/levelabs/level-go/store"
+)
+
+// codeBackend holds code from the deployed block on, and keeps the state of
+// the blocks from retained below the head only, as a pruned node would.
+type codeBackend struct {
+	bind.ContractCaller
+	deployed uint64
+	retained uint64
+}
+
+func (b *codeBackend) CodeAt(ctx context.Context, contract ethcommon.Address, blockNumber *big.Int) ([]byte, error) {
+	block := blockNumber.Uint64()
+	if block < b.retained {
+		return nil, errors.New("missing trie node")
+	}
+	if block < b.deployed {
+		return nil, nil
+	}
+	return []byte{0x60}, nil
+}
+
+func TestLoadCheckpointDeployment(t *testing.T) {
+	const head = 1000
+	tests := []struct {
+		name        string
+		backend     *codeBackend
+		deployBlock uint64
+		deployed    uint64
+		err         error
+	}{
+		{"archive node", &codeBackend{deployed: 421}, 0, 421, nil},
+		{"archive node ignores deploy_block", &codeBackend{deployed: 421}, 400, 421, nil},
+		{"pruned node with deploy_block", &codeBackend{deployed: 421, retained: 900}, 420, 420, nil},
+		{"pruned node", &codeBackend{deployed: 421, retained: 900}, 0, 0, errHistoryUnavailable},
+		{"not deployed", &codeBackend{deployed: head + 1}, 420, 0, errContractNotDeployed},
+	}
+
+	asset := NewAsset(1, "0x0000000000000000000000000000000000000001", 0, 0)
+	for _, test := range tests {
+		s := newTestStore(t)
+		key := store.CheckpointKey(asset.Id())
+		checkpoint, err := loadCheckpoint(context.Background(), s, key, test.backend, asset, head, test.deployBlock)
+		if err != test.err {
+			t.Errorf("%s: loadCheckpoint = %v, want %v", test.name, err, test.err)
+			continue
+		}
+		if err == nil && checkpoint.Deployed != test.deployed {
+			t.Errorf("%s: deployed = %d, want %d", test.name, checkpoint.Deployed, test.deployed)
+		}
+	}
+}
+
+func TestHistoryUnavailableIsPermanent(t *testing.T) {
+	if !DefaultRetryPolicy().Exhausted(1, errHistoryUnavailable) {
+		t.Error("a node without historical state is retried")
+	}
+}
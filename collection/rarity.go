@@ -0,0 +1,189 @@
+package collection
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+var (
+	errRarityScorerNotFound = errors.New("Rarity scorer doesn't exist")
+)
+
+const (
+	RarityStatistical = "statistical"
+	RarityScoreSum    = "rarity_score"
+	RarityInformation = "information_content"
+)
+
+// Scorer turns the frequencies of a collection's traits and the attributes
+// of one of its tokens into a score. Higher scores are rarer for every
+// scorer.
+type Scorer interface {
+	Score(frequencies *Frequencies, attributes []Attribute) float64
+}
+
+// Frequencies reads the trait counts of a collection for scoring, with what
+// every token would otherwise recompute, the entropy and the histograms of
+// numeric traits, computed once.
+type Frequencies struct {
+	trait      *Trait
+	entropy    float64
+	histograms map[string][]Bucket
+}
+
+// Score is the rarity of a single token under one scorer. Tokens tied on
+// score share a rank.
+type Score struct {
+	Id    string  `json:"id"`
+	Score float64 `json:"score"`
+	Rank  int     `json:"rank"`
+}
+
+// StatisticalRarity multiplies the frequencies of a token's trait values,
+// scored as the inverse of that probability.
+type StatisticalRarity struct{}
+
+// RarityScore sums the inverse frequency of each of a token's trait values.
+type RarityScore struct{}
+
+// InformationContent sums the information, -log2(frequency), carried by each
+// trait value and normalizes it by the entropy of the collection, as done by
+// OpenRarity.
+type InformationContent struct{}
+
+var scorers = map[string]Scorer{
+	RarityStatistical: StatisticalRarity{},
+	RarityScoreSum:    RarityScore{},
+	RarityInformation: InformationContent{},
+}
+
+// Scorers lists the names of the available scorers.
+func Scorers() []string {
+	names := make([]string, 0, len(scorers))
+	for name := range scorers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func GetScorer(name string) (Scorer, error) {
+	scorer, ok := scorers[name]
+	if !ok {
+		return nil, errRarityScorerNotFound
+	}
+	return scorer, nil
+}
+
+func NewFrequencies(trait *Trait) *Frequencies {
+	frequencies := Frequencies{
+		trait:      trait,
+		entropy:    trait.Entropy(),
+		histograms: make(map[string][]Bucket, len(trait.Numeric)),
+	}
+	for category, numeric := range trait.Numeric {
+		frequencies.histograms[category] = numeric.Histogram(HistogramBuckets)
+	}
+	return &frequencies
+}
+
+// Entropy is the entropy of the collection, see Trait.Entropy.
+func (f *Frequencies) Entropy() float64 {
+	return f.entropy
+}
+
+// Of is the share of the counted tokens holding a value like the
+// attribute's: the same value for categorical traits, and a value in the
+// same histogram bucket for numeric ones.
+func (f *Frequencies) Of(attribute Attribute) float64 {
+	units := f.trait.units()
+	if units == 0 {
+		return 0
+	}
+
+	if value, ok := attribute.Numeric(); ok {
+		if numeric, ok := f.trait.Numeric[attribute.Trait]; ok {
+			histogram := f.histograms[attribute.Trait]
+			if len(histogram) == 0 || value < numeric.Min || value > numeric.Max {
+				return 0
+			}
+			return histogram[numeric.bucket(value, len(histogram))].Count / units
+		}
+	}
+	return f.trait.Frequency(attribute.Trait, attribute.Value.String())
+}
+
+// Rank scores every token, along with the synthetic attributes of a
+// finalized trait, and orders them from rarest to most common.
+func Rank(scorer Scorer, trait *Trait, tokens []*Token) []Score {
+	frequencies := NewFrequencies(trait)
+
+	scores := make([]Score, len(tokens))
+	for i, token := range tokens {
+		scores[i] = Score{
+			Id:    token.Id,
+			Score: scorer.Score(frequencies, trait.Complete(token.Attributes)),
+		}
+	}
+
+	sort.SliceStable(scores, func(i, j int) bool {
+		if scores[i].Score == scores[j].Score {
+			return scores[i].Id < scores[j].Id
+		}
+		return scores[i].Score > scores[j].Score
+	})
+
+	for i := range scores {
+		if i > 0 && scores[i].Score == scores[i-1].Score {
+			scores[i].Rank = scores[i-1].Rank
+			continue
+		}
+		scores[i].Rank = i + 1
+	}
+	return scores
+}
+
+// Compare ranks the tokens under every available scorer.
+func Compare(trait *Trait, tokens []*Token) map[string][]Score {
+	ranks := make(map[string][]Score, len(scorers))
+	for name, scorer := range scorers {
+		ranks[name] = Rank(scorer, trait, tokens)
+	}
+	return ranks
+}
+
+func (StatisticalRarity) Score(frequencies *Frequencies, attributes []Attribute) float64 {
+	probability := 1.0
+	for _, attribute := range attributes {
+		if f := frequencies.Of(attribute); f > 0 {
+			probability *= f
+		}
+	}
+	return 1 / probability
+}
+
+func (RarityScore) Score(frequencies *Frequencies, attributes []Attribute) float64 {
+	score := 0.0
+	for _, attribute := range attributes {
+		if f := frequencies.Of(attribute); f > 0 {
+			score += 1 / f
+		}
+	}
+	return score
+}
+
+func (InformationContent) Score(frequencies *Frequencies, attributes []Attribute) float64 {
+	information := 0.0
+	for _, attribute := range attributes {
+		if f := frequencies.Of(attribute); f > 0 {
+			information -= math.Log2(f)
+		}
+	}
+
+	entropy := frequencies.Entropy()
+	if entropy == 0 {
+		return 0
+	}
+	return information / entropy
+}
@@ -0,0 +1,93 @@
+package collection
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+// testCollection has one token of a unique Gold fur among three of Blue fur,
+// with a numeric Level spread from 1 to 10.
+func testCollection() (*Trait, []*Token) {
+	furs := []string{"Gold", "Blue", "Blue", "Blue"}
+	levels := []float64{10, 1, 1, 2}
+
+	trait := NewTrait()
+	tokens := make([]*Token, len(furs))
+	for i := range furs {
+		token := Token{Id: strconv.Itoa(i)}
+		token.Attributes = []Attribute{
+			{Trait: "Fur", Value: StringValue(furs[i])},
+			{Trait: "Level", Value: NumberValue(levels[i]), DisplayType: DisplayNumber},
+		}
+		BuildTrait(&token.Attributes, trait)
+		tokens[i] = &token
+	}
+	trait.Finalize(tokens)
+	return trait, tokens
+}
+
+func TestFrequencies(t *testing.T) {
+	trait, _ := testCollection()
+	frequencies := NewFrequencies(trait)
+
+	tests := []struct {
+		attribute Attribute
+		frequency float64
+	}{
+		{Attribute{Trait: "Fur", Value: StringValue("Gold")}, 0.25},
+		{Attribute{Trait: "Fur", Value: StringValue("Blue")}, 0.75},
+		{Attribute{Trait: "Fur", Value: StringValue("Red")}, 0},
+		{Attribute{Trait: "Level", Value: NumberValue(1), DisplayType: DisplayNumber}, 0.5},
+		{Attribute{Trait: "Level", Value: NumberValue(2), DisplayType: DisplayNumber}, 0.25},
+		{Attribute{Trait: "Level", Value: NumberValue(10), DisplayType: DisplayNumber}, 0.25},
+		{Attribute{Trait: "Level", Value: NumberValue(11), DisplayType: DisplayNumber}, 0},
+	}
+	for _, test := range tests {
+		if f := frequencies.Of(test.attribute); math.Abs(f-test.frequency) > 1e-9 {
+			t.Errorf("Of(%s %s) = %f, want %f", test.attribute.Trait, test.attribute.Value, f, test.frequency)
+		}
+	}
+
+	if entropy := frequencies.Entropy(); math.Abs(entropy-trait.Entropy()) > 1e-9 {
+		t.Errorf("Entropy = %f, want %f", entropy, trait.Entropy())
+	}
+}
+
+func TestRank(t *testing.T) {
+	trait, tokens := testCollection()
+
+	for _, name := range Scorers() {
+		scorer, err := GetScorer(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scores := Rank(scorer, trait, tokens)
+
+		// the Gold, level 10 token is rarest; the two Blue, level 1 tokens tie
+		if scores[0].Id != "0" || scores[0].Rank != 1 {
+			t.Errorf("%s: rarest = %+v, want token 0", name, scores[0])
+		}
+		if scores[1].Id != "3" || scores[1].Rank != 2 {
+			t.Errorf("%s: second = %+v, want token 3", name, scores[1])
+		}
+		if scores[2].Rank != 3 || scores[3].Rank != 3 || scores[2].Score != scores[3].Score {
+			t.Errorf("%s: tied tokens = %+v %+v, want both ranked 3", name, scores[2], scores[3])
+		}
+	}
+
+	if _, err := GetScorer("unknown"); err != errRarityScorerNotFound {
+		t.Errorf("GetScorer(unknown) = %v, want %v", err, errRarityScorerNotFound)
+	}
+}
+
+func TestRarityScore(t *testing.T) {
+	trait, tokens := testCollection()
+	frequencies := NewFrequencies(trait)
+
+	// Gold 1/0.25, level 10 1/0.25 and trait count 2 held by every token
+	score := RarityScore{}.Score(frequencies, trait.Complete(tokens[0].Attributes))
+	if math.Abs(score-9) > 1e-9 {
+		t.Errorf("RarityScore = %f, want 9", score)
+	}
+}
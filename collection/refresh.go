@@ -0,0 +1,130 @@
+package collection
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var (
+	errRefreshClassUnknown = errors.New("Refresh class should be hot, normal or archival")
+)
+
+// Refresh classes, from the collections worth refreshing the most often,
+// such as an active mint, to the dormant ones.
+const (
+	ClassHot      = "hot"
+	ClassNormal   = "normal"
+	ClassArchival = "archival"
+)
+
+// classDelays hold back each class once due, so that among collections due
+// around the same time the hotter ones run first.
+var classDelays = map[string]time.Duration{
+	ClassHot:      0,
+	ClassNormal:   10 * time.Second,
+	ClassArchival: time.Minute,
+}
+
+var classPolicies = map[string]RefreshPolicy{
+	ClassHot:      {Class: ClassHot, Interval: time.Minute, MaxStaleness: 5 * time.Minute},
+	ClassNormal:   {Class: ClassNormal, Interval: time.Hour, MaxStaleness: 6 * time.Hour},
+	ClassArchival: {Class: ClassArchival, Interval: 24 * time.Hour, MaxStaleness: 72 * time.Hour},
+}
+
+// RefreshPolicy is how often a tracked collection is sequenced again after a
+// successful run. MaxStaleness bounds how long its class may hold it back.
+type RefreshPolicy struct {
+	Class        string
+	Interval     time.Duration
+	MaxStaleness time.Duration
+}
+
+type refreshPolicyJSON struct {
+	Class        string `json:"class"`
+	Interval     string `json:"interval"`
+	MaxStaleness string `json:"max_staleness"`
+}
+
+// NewRefreshPolicy returns the default policy of a class.
+func NewRefreshPolicy(class string) (RefreshPolicy, error) {
+	policy, ok := classPolicies[class]
+	if !ok {
+		return RefreshPolicy{}, errRefreshClassUnknown
+	}
+	return policy, nil
+}
+
+func DefaultRefreshPolicy() RefreshPolicy {
+	return classPolicies[ClassNormal]
+}
+
+// Validate checks the class is known and fills in the durations left unset
+// from its defaults.
+func (policy *RefreshPolicy) Validate() error {
+	defaults, err := NewRefreshPolicy(policy.Class)
+	if err != nil {
+		return err
+	}
+	if policy.Interval <= 0 {
+		policy.Interval = defaults.Interval
+	}
+	if policy.MaxStaleness <= 0 {
+		policy.MaxStaleness = defaults.MaxStaleness
+	}
+	return nil
+}
+
+// due is when a collection queued to run no earlier than notBefore becomes
+// due, once held back by its class but never past its max staleness.
+func (policy RefreshPolicy) due(notBefore time.Time, lastSuccess time.Time) time.Time {
+	due := notBefore.Add(classDelays[policy.Class])
+	if lastSuccess.IsZero() {
+		return due
+	}
+
+	stale := lastSuccess.Add(policy.MaxStaleness)
+	if due.After(stale) {
+		if notBefore.After(stale) {
+			return notBefore
+		}
+		return stale
+	}
+	return due
+}
+
+func (policy RefreshPolicy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(refreshPolicyJSON{
+		Class:        policy.Class,
+		Interval:     policy.Interval.String(),
+		MaxStaleness: policy.MaxStaleness.String(),
+	})
+}
+
+// UnmarshalJSON reads durations such as "1m" or "24h". Unset fields are
+// filled in from the defaults of the class.
+func (policy *RefreshPolicy) UnmarshalJSON(data []byte) error {
+	var decoded refreshPolicyJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	policy.Class = decoded.Class
+	policy.Interval = 0
+	policy.MaxStaleness = 0
+	if decoded.Interval != "" {
+		interval, err := time.ParseDuration(decoded.Interval)
+		if err != nil {
+			return err
+		}
+		policy.Interval = interval
+	}
+	if decoded.MaxStaleness != "" {
+		staleness, err := time.ParseDuration(decoded.MaxStaleness)
+		if err != nil {
+			return err
+		}
+		policy.MaxStaleness = staleness
+	}
+	return policy.Validate()
+}
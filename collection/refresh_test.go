@@ -0,0 +1,67 @@
+package collection
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestRefreshPolicyDue(t *testing.T) {
+	now := time.Date(2021, 11, 1, 12, 0, 0, 0, time.UTC)
+	hot, _ := NewRefreshPolicy(ClassHot)
+	normal := DefaultRefreshPolicy()
+	archival, _ := NewRefreshPolicy(ClassArchival)
+	tight := RefreshPolicy{Class: ClassArchival, Interval: time.Hour, MaxStaleness: time.Hour}
+
+	tests := []struct {
+		name        string
+		policy      RefreshPolicy
+		notBefore   time.Time
+		lastSuccess time.Time
+		due         time.Time
+	}{
+		{"hot isn't held back", hot, now, now.Add(-time.Minute), now},
+		{"normal held back", normal, now, now.Add(-time.Hour), now.Add(10 * time.Second)},
+		{"archival held back", archival, now, now.Add(-24 * time.Hour), now.Add(time.Minute)},
+		{"never run", archival, now, time.Time{}, now.Add(time.Minute)},
+		{"held back up to its max staleness", tight, now, now.Add(-time.Hour + 30*time.Second), now.Add(30 * time.Second)},
+		{"already stale", tight, now, now.Add(-2 * time.Hour), now},
+	}
+	for _, test := range tests {
+		if due := test.policy.due(test.notBefore, test.lastSuccess); !due.Equal(test.due) {
+			t.Errorf("%s: due = %s, want %s", test.name, due, test.due)
+		}
+	}
+}
+
+func TestRefreshPolicyJSON(t *testing.T) {
+	tests := []struct {
+		raw    string
+		policy RefreshPolicy
+		err    error
+	}{
+		{`{"class":"hot"}`, RefreshPolicy{Class: ClassHot, Interval: time.Minute, MaxStaleness: 5 * time.Minute}, nil},
+		{`{"class":"normal","interval":"30m"}`, RefreshPolicy{Class: ClassNormal, Interval: 30 * time.Minute, MaxStaleness: 6 * time.Hour}, nil},
+		{`{"class":"frozen"}`, RefreshPolicy{}, errRefreshClassUnknown},
+	}
+	for _, test := range tests {
+		var policy RefreshPolicy
+		err := json.Unmarshal([]byte(test.raw), &policy)
+		if err != test.err {
+			t.Errorf("Unmarshal(%s) = %v, want %v", test.raw, err, test.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if policy != test.policy {
+			t.Errorf("Unmarshal(%s) = %+v, want %+v", test.raw, policy, test.policy)
+		}
+
+		encoded, _ := json.Marshal(policy)
+		var decoded RefreshPolicy
+		if err := json.Unmarshal(encoded, &decoded); err != nil || decoded != policy {
+			t.Errorf("round trip of %+v = %+v %v", policy, decoded, err)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package collection
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/levelabs/level-go/store"
+)
+
+var (
+	errReorgTooDeep = errors.New("Reorg reaches below the confirmation depth")
+)
+
+const (
+	defaultConfirmations = 12
+)
+
+// BlockJournal records the transfers applied from an unconfirmed block along
+// with the hash of the block they were read from, so they can be undone if
+// the block leaves the canonical chain.
+type BlockJournal struct {
+	Block     uint64            `json:"block"`
+	Hash      string            `json:"hash"`
+	Transfers []JournalTransfer `json:"transfers"`
+}
+
+type JournalTransfer struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	TokenId string `json:"token_id"`
+}
+
+func NewJournalTransfer(transfer *CollectionTransfer) JournalTransfer {
+	return JournalTransfer{
+		From:    transfer.From.Hex(),
+		To:      transfer.To.Hex(),
+		TokenId: transfer.TokenId.String(),
+	}
+}
+
+// inverse is the transfer undoing this one.
+func (transfer JournalTransfer) inverse() *CollectionTransfer {
+	id, _ := new(big.Int).SetString(transfer.TokenId, 10)
+	return &CollectionTransfer{
+		From:    ethcommon.HexToAddress(transfer.To),
+		To:      ethcommon.HexToAddress(transfer.From),
+		TokenId: id,
+	}
+}
+
+// confirmations is the depth below the head past which blocks of the chain
+// are treated as final.
+func confirmations(ethereum *Ethereum) uint64 {
+	if ethereum.Confirmations == 0 {
+		return defaultConfirmations
+	}
+	return ethereum.Confirmations
+}
+
+// confirmedBelow is the highest block considered final at head.
+func confirmedBelow(head uint64, confirmations uint64) uint64 {
+	if head < confirmations {
+		return 0
+	}
+	return head - confirmations
+}
+
+// journals reads the hashes of the unconfirmed blocks in [from, to] and
+// groups their transfers by block.
+func (indexer *OwnershipIndexer) journals(
+	ctx context.Context,
+	ethereum *Ethereum,
+	transfers []*CollectionTransfer,
+	from uint64,
+	to uint64,
+	head uint64,
+) (map[uint64]*BlockJournal, error) {
+	start := confirmedBelow(head, confirmations(ethereum)) + 1
+	if start < from {
+		start = from
+	}
+
+	journals := make(map[uint64]*BlockJournal)
+	for block := start; block <= to; block++ {
+		header, err := ethereum.Client.HeaderByNumber(ctx, new(big.Int).SetUint64(block))
+		if err != nil {
+			return nil, err
+		}
+		journals[block] = &BlockJournal{Block: block, Hash: header.Hash().Hex()}
+	}
+
+	for _, transfer := range transfers {
+		if journal, ok := journals[transfer.Raw.BlockNumber]; ok {
+			journal.Transfers = append(journal.Transfers, NewJournalTransfer(transfer))
+		}
+	}
+	return journals, nil
+}
+
+// headerReader reads the canonical header at a block number.
+type headerReader interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// Reconcile compares the journaled blocks of a collection with the canonical
+// chain and rolls back every block past the last one still on it.
+func (indexer *OwnershipIndexer) Reconcile(
+	ctx context.Context,
+	ethereum *Ethereum,
+	assetId string,
+	checkpoint *Checkpoint,
+) error {
+	return indexer.reconcile(ctx, ethereum.Client, assetId, checkpoint)
+}
+
+func (indexer *OwnershipIndexer) reconcile(
+	ctx context.Context,
+	headers headerReader,
+	assetId string,
+	checkpoint *Checkpoint,
+) error {
+	journals, err := LoadJournals(indexer.store, assetId)
+	if err != nil {
+		return err
+	}
+
+	canonical := checkpoint.Confirmed
+	for i := len(journals) - 1; i >= 0; i-- {
+		journal := journals[i]
+		header, err := headers.HeaderByNumber(ctx, new(big.Int).SetUint64(journal.Block))
+		if err != nil {
+			return err
+		}
+		if header.Hash().Hex() == journal.Hash {
+			canonical = journal.Block
+			break
+		}
+	}
+
+	if len(journals) == 0 || canonical == journals[len(journals)-1].Block {
+		return nil
+	}
+	return indexer.Rollback(assetId, checkpoint, canonical)
+}
+
+// Rollback undoes the transfers of every journaled block above block, most
+// recent first, and moves the checkpoint back so they are read again from the
+// canonical chain.
+func (indexer *OwnershipIndexer) Rollback(assetId string, checkpoint *Checkpoint, block uint64) error {
+	journals, err := LoadJournals(indexer.store, assetId)
+	if err != nil {
+		return err
+	}
+
+	if block < checkpoint.Confirmed {
+		log.Printf("[WARN]: %s reorg to %d below confirmed %d", assetId, block, checkpoint.Confirmed)
+	}
+	log.Printf("[REORG]: %s rolling back to %d\n", assetId, block)
+
+	if checkpoint.Block > block {
+		checkpoint.Block = block
+	}
+
+	err = indexer.store.WriteBatch(func(batch *store.Batch) error {
+		for i := len(journals) - 1; i >= 0 && journals[i].Block > block; i-- {
+			transfers := journals[i].Transfers
+			for j := len(transfers) - 1; j >= 0; j-- {
+				if err := ApplyTransfer(batch, assetId, transfers[j].inverse()); err != nil {
+					return err
+				}
+			}
+			if err := batch.Delete(store.JournalKey(assetId, journals[i].Block)); err != nil {
+				return err
+			}
+		}
+		return batch.Set(store.CheckpointKey(assetId), checkpoint)
+	})
+	if err != nil {
+		return err
+	}
+
+	if block < checkpoint.Confirmed {
+		return errReorgTooDeep
+	}
+	return nil
+}
+
+// LoadJournals reads the journals of a collection in block order.
+func LoadJournals(s *store.Store, assetId string) ([]*BlockJournal, error) {
+	var journals []*BlockJournal
+	err := s.Iterate(store.JournalPrefix(assetId), func(key []byte, value []byte) error {
+		var journal BlockJournal
+		if err := json.Unmarshal(value, &journal); err != nil {
+			return err
+		}
+		journals = append(journals, &journal)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(journals, func(i, j int) bool {
+		return journals[i].Block < journals[j].Block
+	})
+	return journals, nil
+}
+
+// confirmedJournals lists the blocks of the journals at or below confirmed,
+// which can no longer be reorged out and are dropped.
+func confirmedJournals(s *store.Store, assetId string, confirmed uint64) ([]uint64, error) {
+	prefix := store.JournalPrefix(assetId)
+
+	var blocks []uint64
+	err := s.Iterate(prefix, func(key []byte, value []byte) error {
+		block, err := strconv.ParseUint(strings.TrimPrefix(string(key), string(prefix)), 10, 64)
+		if err != nil {
+			return err
+		}
+		if block <= confirmed {
+			blocks = append(blocks, block)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
@@ -0,0 +1,208 @@
+package collection
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/levelabs/level-go/store"
+)
+
+const testAsset = "1:0x0000000000000000000000000000000000000001"
+
+var (
+	holderA = ethcommon.HexToAddress("0x000000000000000000000000000000000000000a")
+	holderB = ethcommon.HexToAddress("0x000000000000000000000000000000000000000b")
+)
+
+// testHeader is the header of block on the fork numbered fork, each fork
+// hashing differently.
+func testHeader(block uint64, fork int64) *types.Header {
+	return &types.Header{Number: new(big.Int).SetUint64(block), Extra: big.NewInt(fork).Bytes()}
+}
+
+func testTransfer(from ethcommon.Address, to ethcommon.Address, id int64, header *types.Header) *CollectionTransfer {
+	return &CollectionTransfer{
+		From:    from,
+		To:      to,
+		TokenId: big.NewInt(id),
+		Raw:     types.Log{BlockNumber: header.Number.Uint64(), BlockHash: header.Hash()},
+	}
+}
+
+func ownerOf(t *testing.T, s *store.Store, id string) string {
+	var owner string
+	err := s.Get(store.OwnerKey(testAsset, id), &owner)
+	if err == store.ErrNotFound {
+		return ""
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return owner
+}
+
+func journaledBlocks(t *testing.T, s *store.Store) []uint64 {
+	journals, err := LoadJournals(s, testAsset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks := make([]uint64, len(journals))
+	for i, journal := range journals {
+		blocks[i] = journal.Block
+	}
+	return blocks
+}
+
+// liveIndexer has token 1 minted to A in block 101, then moved to B in
+// block 102, both received live and unconfirmed.
+func liveIndexer(t *testing.T) (*OwnershipIndexer, *Ethereum, *Checkpoint) {
+	s := newTestStore(t)
+	indexer := NewOwnershipIndexer(nil, s)
+	ethereum := &Ethereum{ChainId: 1, Confirmations: 10}
+	checkpoint := &Checkpoint{Block: 100, Confirmed: 90}
+
+	transfers := []*CollectionTransfer{
+		testTransfer(ethcommon.Address{}, holderA, 1, testHeader(101, 0)),
+		testTransfer(holderA, holderB, 1, testHeader(102, 0)),
+	}
+	for _, transfer := range transfers {
+		if err := indexer.applyLive(ethereum, testAsset, transfer, checkpoint); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if owner := ownerOf(t, s, "1"); owner != holderB.Hex() {
+		t.Fatalf("owner of 1 = %s, want %s", owner, holderB.Hex())
+	}
+	return indexer, ethereum, checkpoint
+}
+
+func TestApplyLiveHashMismatch(t *testing.T) {
+	indexer, ethereum, checkpoint := liveIndexer(t)
+	s := indexer.store
+
+	// block 102 comes back from another fork, with token 2 minted instead
+	fork := testTransfer(ethcommon.Address{}, holderB, 2, testHeader(102, 1))
+	if err := indexer.applyLive(ethereum, testAsset, fork, checkpoint); err != nil {
+		t.Fatal(err)
+	}
+
+	if owner := ownerOf(t, s, "1"); owner != holderA.Hex() {
+		t.Errorf("owner of 1 = %s, want the transfer of the old fork undone", owner)
+	}
+	if owner := ownerOf(t, s, "2"); owner != holderB.Hex() {
+		t.Errorf("owner of 2 = %s, want %s", owner, holderB.Hex())
+	}
+	var holding bool
+	if err := s.Get(store.HoldingKey(testAsset, holderB.Hex(), "1"), &holding); err != store.ErrNotFound {
+		t.Errorf("B still holds 1 after the rollback: %v", err)
+	}
+
+	var journal BlockJournal
+	if err := s.Get(store.JournalKey(testAsset, 102), &journal); err != nil {
+		t.Fatal(err)
+	}
+	if journal.Hash != testHeader(102, 1).Hash().Hex() || len(journal.Transfers) != 1 || journal.Transfers[0].TokenId != "2" {
+		t.Errorf("journal of 102 = %+v, want only the transfer of the new fork", journal)
+	}
+	if checkpoint.Block != 101 {
+		t.Errorf("checkpoint = %d, want 101", checkpoint.Block)
+	}
+}
+
+func TestApplyLiveRemoved(t *testing.T) {
+	indexer, ethereum, checkpoint := liveIndexer(t)
+	s := indexer.store
+
+	removed := testTransfer(holderA, holderB, 1, testHeader(102, 0))
+	removed.Raw.Removed = true
+	if err := indexer.applyLive(ethereum, testAsset, removed, checkpoint); err != nil {
+		t.Fatal(err)
+	}
+
+	if owner := ownerOf(t, s, "1"); owner != holderA.Hex() {
+		t.Errorf("owner of 1 = %s, want %s", owner, holderA.Hex())
+	}
+	if blocks := journaledBlocks(t, s); len(blocks) != 1 || blocks[0] != 101 {
+		t.Errorf("journaled blocks = %v, want [101]", blocks)
+	}
+
+	var stored Checkpoint
+	if err := s.Get(store.CheckpointKey(testAsset), &stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored.Block != 101 {
+		t.Errorf("stored checkpoint = %d, want 101", stored.Block)
+	}
+}
+
+func TestApplyLivePrunesConfirmedJournals(t *testing.T) {
+	indexer, ethereum, checkpoint := liveIndexer(t)
+
+	// block 112 confirms 102 and below at a depth of 10
+	later := testTransfer(holderB, holderA, 1, testHeader(112, 0))
+	if err := indexer.applyLive(ethereum, testAsset, later, checkpoint); err != nil {
+		t.Fatal(err)
+	}
+
+	if blocks := journaledBlocks(t, indexer.store); len(blocks) != 1 || blocks[0] != 112 {
+		t.Errorf("journaled blocks = %v, want [112]", blocks)
+	}
+	if checkpoint.Confirmed != 102 {
+		t.Errorf("confirmed = %d, want 102", checkpoint.Confirmed)
+	}
+}
+
+// testHeaders serves the canonical chain: fork 0 up to block forkedAt, and
+// fork 1 above it.
+type testHeaders struct {
+	forkedAt uint64
+}
+
+func (h testHeaders) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if number.Uint64() > h.forkedAt {
+		return testHeader(number.Uint64(), 1), nil
+	}
+	return testHeader(number.Uint64(), 0), nil
+}
+
+func TestReconcile(t *testing.T) {
+	tests := []struct {
+		name     string
+		forkedAt uint64
+		owner    ethcommon.Address
+		blocks   int
+		block    uint64
+		err      error
+	}{
+		{"no reorg", 200, holderB, 2, 101, nil},
+		{"last block reorged", 101, holderA, 1, 101, nil},
+		{"every journaled block reorged", 100, ethcommon.Address{}, 0, 92, nil},
+	}
+
+	for _, test := range tests {
+		indexer, _, checkpoint := liveIndexer(t)
+		err := indexer.reconcile(context.Background(), testHeaders{forkedAt: test.forkedAt}, testAsset, checkpoint)
+		if err != test.err {
+			t.Errorf("%s: reconcile = %v, want %v", test.name, err, test.err)
+			continue
+		}
+
+		owner := ""
+		if test.owner != (ethcommon.Address{}) {
+			owner = test.owner.Hex()
+		}
+		if got := ownerOf(t, indexer.store, "1"); got != owner {
+			t.Errorf("%s: owner of 1 = %q, want %q", test.name, got, owner)
+		}
+		if blocks := journaledBlocks(t, indexer.store); len(blocks) != test.blocks {
+			t.Errorf("%s: journaled blocks = %v, want %d", test.name, blocks, test.blocks)
+		}
+		if checkpoint.Block != test.block {
+			t.Errorf("%s: checkpoint = %d, want %d", test.name, checkpoint.Block, test.block)
+		}
+	}
+}
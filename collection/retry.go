@@ -0,0 +1,200 @@
+package collection
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/levelabs/level-go/store"
+)
+
+var (
+	ErrDeadLetterNotFound = errors.New("Collection isn't on the dead-letter list")
+)
+
+// Kinds of errors a sequence fails with. Permanent kinds won't go away by
+// retrying, so they dead-letter the collection on the first failure.
+const (
+	ErrorKindUnknown     = "unknown"
+	ErrorKindConfig      = "config"
+	ErrorKindUnsupported = "unsupported"
+	ErrorKindContract    = "contract"
+	ErrorKindUri         = "uri"
+	ErrorKindFetch       = "fetch"
+)
+
+var errorKinds = map[error]string{
+	ErrChainNotConfigured:           ErrorKindConfig,
+	errHistoryUnavailable:           ErrorKindConfig,
+	errContractUnsupported:          ErrorKindUnsupported,
+	errMetadataUnsupported:          ErrorKindUnsupported,
+	errCreatingCollectionEthBinding: ErrorKindContract,
+	errTokenEnumerationFailed:       ErrorKindContract,
+	errContractNotDeployed:          ErrorKindContract,
+	errURIFormatNotFound:            ErrorKindUri,
+	errArweaveManifestInvalid:       ErrorKindUri,
+	errTokenUriNotExist:             ErrorKindUri,
+	errDataUriFormat:                ErrorKindUri,
+	errDataUriMediaType:             ErrorKindUri,
+	errIPFSPathInvalid:              ErrorKindUri,
+	errClientIPFSGet:                ErrorKindFetch,
+	errClientHttpGet:                ErrorKindFetch,
+	errClientArweaveGet:             ErrorKindFetch,
+}
+
+var permanentKinds = map[string]bool{
+	ErrorKindConfig:      true,
+	ErrorKindUnsupported: true,
+}
+
+// ErrorKind classifies the error a sequence failed with.
+func ErrorKind(err error) string {
+	if kind, ok := errorKinds[err]; ok {
+		return kind
+	}
+	return ErrorKindUnknown
+}
+
+// RetryPolicy spaces out the runs of a failing collection. The n-th retry
+// waits BaseDelay * 2^(n-1), capped at MaxDelay and shifted by up to Jitter
+// of itself either way, and MaxAttempts failures dead-letter the collection.
+type RetryPolicy struct {
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Jitter      float64
+	MaxAttempts int
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		BaseDelay:   30 * time.Second,
+		MaxDelay:    6 * time.Hour,
+		Jitter:      0.2,
+		MaxAttempts: 8,
+	}
+}
+
+// Delay is how long to wait before the retry following attempts failures.
+func (policy RetryPolicy) Delay(attempts int) time.Duration {
+	delay := policy.BaseDelay
+	for i := 1; i < attempts && delay < policy.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > policy.MaxDelay {
+		delay = policy.MaxDelay
+	}
+
+	if policy.Jitter > 0 {
+		delay += time.Duration((rand.Float64()*2 - 1) * policy.Jitter * float64(delay))
+	}
+	return delay
+}
+
+// Exhausted tells whether a collection failing with err after attempts
+// failures should stop being retried.
+func (policy RetryPolicy) Exhausted(attempts int, err error) bool {
+	return attempts >= policy.MaxAttempts || permanentKinds[ErrorKind(err)]
+}
+
+// DeadLetter is a collection taken off the waitlist, with the failure which
+// took it off and the tracking it is restored with once requeued.
+type DeadLetter struct {
+	Collection string    `json:"collection"`
+	Attempts   int       `json:"attempts"`
+	Kind       string    `json:"kind"`
+	Error      string    `json:"error"`
+	DeadAt     time.Time `json:"dead_at"`
+
+	Policy      RefreshPolicy `json:"policy"`
+	Paused      bool          `json:"paused,omitempty"`
+	LastSuccess time.Time     `json:"last_success,omitempty"`
+}
+
+// deadLetter moves the asset from the waitlist to the dead-letter list.
+func deadLetter(s *store.Store, asset *Asset, err error) error {
+	letter := DeadLetter{
+		Collection: asset.Id(),
+		Attempts:   asset.attempts,
+		Kind:       ErrorKind(err),
+		Error:      err.Error(),
+		DeadAt:     time.Now(),
+
+		Policy:      asset.policy,
+		Paused:      asset.paused,
+		LastSuccess: asset.lastSuccess,
+	}
+
+	return s.WriteBatch(func(batch *store.Batch) error {
+		if err := batch.Delete(store.WaitlistKey(asset.Id())); err != nil {
+			return err
+		}
+		return batch.Set(store.DeadLetterKey(asset.Id()), &letter)
+	})
+}
+
+func LoadDeadLetter(s *store.Store, assetId string) (*DeadLetter, error) {
+	var letter DeadLetter
+	if err := s.Get(store.DeadLetterKey(assetId), &letter); err != nil {
+		return nil, err
+	}
+	return &letter, nil
+}
+
+// LoadDeadLetters reads back every collection on the dead-letter list.
+func LoadDeadLetters(s *store.Store) ([]*DeadLetter, error) {
+	var letters []*DeadLetter
+	err := s.Iterate(store.DeadLetterPrefix(), func(key []byte, value []byte) error {
+		var letter DeadLetter
+		if err := json.Unmarshal(value, &letter); err != nil {
+			return err
+		}
+		letters = append(letters, &letter)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return letters, nil
+}
+
+// RequeueAsset takes a collection off the dead-letter list and stores its
+// waitlist entry again, due now with its failures forgotten and its refresh
+// policy and paused state restored. A running manager only picks it up on
+// its next start.
+func RequeueAsset(s *store.Store, assetId string) (*WaitlistEntry, error) {
+	id, err := NormalizeAssetId(assetId)
+	if err != nil {
+		return nil, err
+	}
+
+	letter, err := LoadDeadLetter(s, id)
+	if err == store.ErrNotFound {
+		return nil, ErrDeadLetterNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entry := WaitlistEntry{
+		Collection:  id,
+		Policy:      letter.Policy,
+		Paused:      letter.Paused,
+		Priority:    time.Now().UnixNano(),
+		LastSuccess: letter.LastSuccess,
+	}
+	if entry.Policy.Class == "" {
+		entry.Policy = DefaultRefreshPolicy()
+	}
+
+	err = s.WriteBatch(func(batch *store.Batch) error {
+		if err := batch.Delete(store.DeadLetterKey(id)); err != nil {
+			return err
+		}
+		return batch.Set(store.WaitlistKey(id), &entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
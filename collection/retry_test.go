@@ -0,0 +1,134 @@
+package collection
+
+import (
+	"testing"
+	"time"
+
+	"github.com/levelabs/level-go/store"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 30 * time.Second, MaxDelay: 6 * time.Hour, MaxAttempts: 8}
+	tests := []struct {
+		attempts int
+		delay    time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{9, 128 * time.Minute},
+		{11, 6 * time.Hour},
+		{100, 6 * time.Hour},
+	}
+	for _, test := range tests {
+		if delay := policy.Delay(test.attempts); delay != test.delay {
+			t.Errorf("Delay(%d) = %s, want %s", test.attempts, delay, test.delay)
+		}
+	}
+
+	policy.Jitter = 0.2
+	for i := 0; i < 100; i++ {
+		delay := policy.Delay(3)
+		if delay < 96*time.Second || delay > 144*time.Second {
+			t.Fatalf("Delay(3) with jitter = %s, want within 20%% of 2m", delay)
+		}
+	}
+}
+
+func TestRetryPolicyExhausted(t *testing.T) {
+	policy := DefaultRetryPolicy()
+	tests := []struct {
+		attempts  int
+		err       error
+		exhausted bool
+	}{
+		{1, errClientIPFSGet, false},
+		{policy.MaxAttempts, errClientIPFSGet, true},
+		{1, ErrChainNotConfigured, true},
+		{1, errContractUnsupported, true},
+	}
+	for _, test := range tests {
+		if exhausted := policy.Exhausted(test.attempts, test.err); exhausted != test.exhausted {
+			t.Errorf("Exhausted(%d, %v) = %v, want %v", test.attempts, test.err, exhausted, test.exhausted)
+		}
+	}
+}
+
+func TestRequeueAssetRestoresTracking(t *testing.T) {
+	s := newTestStore(t)
+	policy, _ := NewRefreshPolicy(ClassHot)
+
+	asset := NewAsset(1, "0x0000000000000000000000000000000000000001", 0, 0)
+	asset.policy = policy
+	asset.paused = true
+	asset.attempts = 8
+	if err := SaveWaitlistEntry(s, asset); err != nil {
+		t.Fatal(err)
+	}
+	if err := deadLetter(s, asset, errClientIPFSGet); err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := RequeueAsset(s, asset.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Policy != policy || !entry.Paused || entry.Attempts != 0 {
+		t.Errorf("requeued entry = %+v, want the hot policy, paused and no attempts", entry)
+	}
+	if _, err := LoadDeadLetter(s, asset.Id()); err == nil {
+		t.Error("dead letter kept after requeue")
+	}
+	if _, err := RequeueAsset(s, asset.Id()); err != ErrDeadLetterNotFound {
+		t.Errorf("second requeue = %v, want %v", err, ErrDeadLetterNotFound)
+	}
+}
+
+func TestRetryLeavesDroppedAssets(t *testing.T) {
+	for _, drop := range []string{"untrack", "pause"} {
+		s := newTestStore(t)
+		now := time.Now()
+		manager := Manager{
+			Store:    s,
+			Waitlist: newTestWaitlist(now, "0x0000000000000000000000000000000000000001"),
+			Retry:    DefaultRetryPolicy(),
+		}
+
+		asset, err := manager.Waitlist.Lease(now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := SaveWaitlistEntry(s, asset); err != nil {
+			t.Fatal(err)
+		}
+
+		if drop == "untrack" {
+			err = manager.Untrack(asset.Id())
+		} else {
+			_, err = manager.Pause(asset.Id())
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// a permanent failure would dead-letter the asset on its first run
+		manager.fail(asset, ErrChainNotConfigured)
+
+		if _, err := LoadDeadLetter(s, asset.Id()); err != store.ErrNotFound {
+			t.Errorf("%s: dead letter = %v, want none", drop, err)
+		}
+		if state := manager.Waitlist.State(asset.Id()); state != "" || manager.Waitlist.Dropped(asset.Id()) {
+			t.Errorf("%s: waitlist state = %q, want the lease released", drop, state)
+		}
+
+		var entry WaitlistEntry
+		err = s.Get(store.WaitlistKey(asset.Id()), &entry)
+		if drop == "untrack" && err != store.ErrNotFound {
+			t.Errorf("untrack: waitlist entry = %v, want none", err)
+		}
+		if drop == "pause" && (err != nil || !entry.Paused) {
+			t.Errorf("pause: waitlist entry = %+v %v, want it kept paused", entry, err)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package collection
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+)
+
+// declinedCall are the messages of a call answered by the contract itself,
+// reverting or returning nothing, as opposed to a call which never reached
+// it.
+var declinedCall = []string{
+	"execution reverted",
+	"attempting to unmarshall an empty string",
+}
+
+// callDeclined tells whether a contract call failed because the contract
+// doesn't implement it, so retrying is pointless. Transport and node errors,
+// which may succeed on the next try, aren't declined calls.
+func callDeclined(err error) bool {
+	if err == nil || errors.Is(err, bind.ErrNoCode) {
+		return false
+	}
+	message := strings.ToLower(err.Error())
+	for _, declined := range declinedCall {
+		if strings.Contains(message, declined) {
+			return true
+		}
+	}
+	return false
+}
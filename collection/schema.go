@@ -0,0 +1,104 @@
+package collection
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var (
+	errAssetSchemaUnknown = errors.New("Asset was encoded with an unknown schema version")
+	errAssetLegacyFormat  = errors.New("Asset legacy encoding couldn't be parsed")
+)
+
+// AssetSchemaVersion is the version written by Asset.MarshalJSON.
+const AssetSchemaVersion = 2
+
+// Migration upgrades the fields of an encoded asset by one schema version.
+type Migration func(fields map[string]json.RawMessage) error
+
+// migrations holds the upgrade from each version to the next.
+var migrations = map[int]Migration{
+	0: migrateLegacyAsset,
+	1: migrateMainnetAsset,
+}
+
+// RegisterMigration sets the upgrade from version to version + 1, to be
+// registered alongside any bump of AssetSchemaVersion.
+func RegisterMigration(version int, migration Migration) {
+	migrations[version] = migration
+}
+
+// decodeAssetFields reads an encoded asset into its fields, upgraded to the
+// current schema version.
+func decodeAssetFields(data []byte) (map[string]json.RawMessage, error) {
+	fields, err := readAssetFields(data)
+	if err != nil {
+		return nil, err
+	}
+
+	version := 0
+	if raw, ok := fields["version"]; ok {
+		if err := json.Unmarshal(raw, &version); err != nil {
+			return nil, err
+		}
+	}
+	if version > AssetSchemaVersion {
+		return nil, errAssetSchemaUnknown
+	}
+
+	for ; version < AssetSchemaVersion; version++ {
+		migration, ok := migrations[version]
+		if !ok {
+			return nil, errAssetSchemaUnknown
+		}
+		if err := migration(fields); err != nil {
+			return nil, err
+		}
+	}
+	fields["version"] = json.RawMessage(strconv.Itoa(AssetSchemaVersion))
+	return fields, nil
+}
+
+// readAssetFields accepts both the object encoding and the legacy
+// "<address> - <supply>" string written before versioning.
+func readAssetFields(data []byte) (map[string]json.RawMessage, error) {
+	var legacy string
+	if err := json.Unmarshal(data, &legacy); err == nil {
+		parts := strings.SplitN(legacy, " - ", 2)
+		if len(parts) != 2 {
+			return nil, errAssetLegacyFormat
+		}
+		address, _ := json.Marshal(parts[0])
+		supply, _ := json.Marshal(parts[1])
+		return map[string]json.RawMessage{
+			"address":     address,
+			"tokenSupply": supply,
+		}, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// migrateLegacyAsset renames the unversioned tokenSupply field.
+func migrateLegacyAsset(fields map[string]json.RawMessage) error {
+	if supply, ok := fields["tokenSupply"]; ok {
+		fields["total_supply"] = supply
+		delete(fields, "tokenSupply")
+	}
+	return nil
+}
+
+// migrateMainnetAsset places the assets written before multi-chain support
+// on mainnet.
+func migrateMainnetAsset(fields map[string]json.RawMessage) error {
+	if _, ok := fields["chain_id"]; !ok {
+		fields["chain_id"] = json.RawMessage(strconv.FormatUint(ChainMainnet, 10))
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package collection
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+
+	"github.com/levelabs/level-go/store"
+)
+
+const testAddress = "0xBC4CA0EdA7647A8aB7C2061c2E118A18a936f13D"
+
+func TestAssetMigration(t *testing.T) {
+	tests := []struct {
+		name    string
+		encoded string
+		chainId uint64
+		supply  string
+		uri     *Uri
+	}{
+		{"legacy string", `"` + testAddress + ` - 10000"`, ChainMainnet, "10000", nil},
+		{
+			"unversioned object",
+			`{"address":"` + testAddress + `","tokenSupply":"42","uri":{"scheme":1,"host":"` + testCidV0 + `"}}`,
+			ChainMainnet, "42", &Uri{Scheme: UriIPFS, Host: testCidV0},
+		},
+		{"version 1", `{"version":1,"address":"` + testAddress + `","total_supply":"7"}`, ChainMainnet, "7", nil},
+		{"version 2", `{"version":2,"chain_id":137,"address":"` + testAddress + `","total_supply":"7"}`, ChainPolygon, "7", nil},
+	}
+	for _, test := range tests {
+		var asset Asset
+		if err := json.Unmarshal([]byte(test.encoded), &asset); err != nil {
+			t.Errorf("%s: Unmarshal = %v", test.name, err)
+			continue
+		}
+		if asset.ChainId() != test.chainId || asset.Address() != testAddress {
+			t.Errorf("%s: asset = %s, want %d:%s", test.name, asset.Id(), test.chainId, testAddress)
+		}
+		if asset.TotalSupply().String() != test.supply {
+			t.Errorf("%s: supply = %s, want %s", test.name, asset.TotalSupply(), test.supply)
+		}
+		if !reflect.DeepEqual(asset.Uri(), test.uri) {
+			t.Errorf("%s: uri = %+v, want %+v", test.name, asset.Uri(), test.uri)
+		}
+	}
+}
+
+func TestAssetRoundTrip(t *testing.T) {
+	asset := NewAsset(ChainPolygon, testAddress, 0, 0)
+	asset.uri = &Uri{Scheme: UriArweave, Host: "abc", Path: "tokens/"}
+	asset.totalSupply.SetString("123456789012345678901234567890", 10)
+
+	encoded, err := json.Marshal(asset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(encoded), `"version":2`) {
+		t.Errorf("encoded = %s, want version %d", encoded, AssetSchemaVersion)
+	}
+
+	var decoded Asset
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Id() != asset.Id() || decoded.TotalSupply().Cmp(asset.TotalSupply()) != 0 || *decoded.Uri() != *asset.Uri() {
+		t.Errorf("round trip = %s %s %+v, want %s %s %+v",
+			decoded.Id(), decoded.TotalSupply(), decoded.Uri(), asset.Id(), asset.TotalSupply(), asset.Uri())
+	}
+}
+
+func TestAssetMigrationErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		encoded string
+		err     error
+	}{
+		{"future version", `{"version":3,"address":"` + testAddress + `"}`, errAssetSchemaUnknown},
+		{"legacy without supply", `"` + testAddress + `"`, errAssetLegacyFormat},
+	}
+	for _, test := range tests {
+		var asset Asset
+		if err := json.Unmarshal([]byte(test.encoded), &asset); err != test.err {
+			t.Errorf("%s: Unmarshal = %v, want %v", test.name, err, test.err)
+		}
+	}
+}
+
+func TestMigrateLegacyAssets(t *testing.T) {
+	s := newTestStore(t)
+	address := ethcommon.HexToAddress(testAddress)
+	other := ethcommon.HexToAddress("0x0000000000000000000000000000000000000002")
+
+	// baseline records, keyed by the raw address
+	if err := s.Set(address.Bytes(), testAddress+" - 10000"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set(other.Bytes(), other.Hex()+" - 5"); err != nil {
+		t.Fatal(err)
+	}
+	// the second collection was already stored under its new key
+	current := NewAsset(ChainMainnet, other.Hex(), 0, 0)
+	current.totalSupply.SetInt64(6)
+	if err := SaveAsset(s, current); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := MigrateLegacyAssets(s); err != nil {
+		t.Fatal(err)
+	}
+
+	asset, err := LoadAsset(s, AssetId(ChainMainnet, testAddress))
+	if err != nil {
+		t.Fatalf("LoadAsset of the legacy record = %v", err)
+	}
+	if asset.TotalSupply().String() != "10000" {
+		t.Errorf("migrated supply = %s, want 10000", asset.TotalSupply())
+	}
+	kept, err := LoadAsset(s, current.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kept.TotalSupply().String() != "6" {
+		t.Errorf("supply of %s = %s, want the stored 6 kept", current.Id(), kept.TotalSupply())
+	}
+
+	for _, key := range [][]byte{address.Bytes(), other.Bytes()} {
+		var value string
+		if err := s.Get(key, &value); err != store.ErrNotFound {
+			t.Errorf("legacy key %x still stored: %v", key, err)
+		}
+	}
+	assets, err := LoadAssets(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(assets) != 2 {
+		t.Errorf("LoadAssets = %d assets, want 2", len(assets))
+	}
+}
@@ -0,0 +1,162 @@
+package collection
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+
+	"github.com/levelabs/level-go/store"
+)
+
+// TokenRecord is the stored metadata of a single token.
+type TokenRecord struct {
+	Collection string `json:"collection"`
+	Id         string `json:"id"`
+	Metadata
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+func NewTokenRecord(assetId string, token *Token) TokenRecord {
+	return TokenRecord{
+		Collection: assetId,
+		Id:         token.Id,
+		Metadata:   token.Metadata,
+		FetchedAt:  token.FetchedAt,
+	}
+}
+
+// Token rebuilds the token the record was stored from.
+func (record *TokenRecord) Token() *Token {
+	return &Token{
+		Id:        record.Id,
+		FetchedAt: record.FetchedAt,
+		Metadata:  record.Metadata,
+	}
+}
+
+// SaveAsset stores the snapshot of a collection: its uri, supply and trait
+// counts.
+func SaveAsset(s *store.Store, asset *Asset) error {
+	return s.Set(store.CollectionKey(asset.Id()), asset)
+}
+
+func LoadAsset(s *store.Store, assetId string) (*Asset, error) {
+	var asset Asset
+	if err := s.Get(store.CollectionKey(assetId), &asset); err != nil {
+		return nil, err
+	}
+	return &asset, nil
+}
+
+// LoadAssets reads back the snapshot of every stored collection.
+func LoadAssets(s *store.Store) ([]*Asset, error) {
+	var assets []*Asset
+	err := s.Iterate(store.CollectionPrefix(), func(key []byte, value []byte) error {
+		var asset Asset
+		if err := json.Unmarshal(value, &asset); err != nil {
+			return err
+		}
+		assets = append(assets, &asset)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return assets, nil
+}
+
+// MigrateLegacyAssets moves the snapshots written before versioning, stored
+// under the raw bytes of the collection address, to their collection key.
+// A snapshot already stored under the new key is kept over the legacy one.
+// It runs once the store is opened, before anything reads it.
+func MigrateLegacyAssets(s *store.Store) error {
+	legacy := make(map[string][]byte)
+	err := s.Iterate(nil, func(key []byte, value []byte) error {
+		if len(key) == ethcommon.AddressLength {
+			legacy[string(key)] = append([]byte(nil), value...)
+		}
+		return nil
+	})
+	if err != nil || len(legacy) == 0 {
+		return err
+	}
+
+	return s.WriteBatch(func(batch *store.Batch) error {
+		for key, value := range legacy {
+			var asset Asset
+			if err := json.Unmarshal(value, &asset); err != nil {
+				log.Printf("[WARN]: Skipping legacy record %x %s", key, err)
+				continue
+			}
+			if _, err := LoadAsset(s, asset.Id()); err == store.ErrNotFound {
+				if err := batch.Set(store.CollectionKey(asset.Id()), &asset); err != nil {
+					return err
+				}
+			} else if err != nil {
+				return err
+			}
+			if err := batch.Delete([]byte(key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SaveTokens stores a record for every token read by the asset's last crawl.
+func SaveTokens(s *store.Store, asset *Asset) error {
+	assetId := asset.Id()
+
+	return s.WriteBatch(func(batch *store.Batch) error {
+		for _, token := range asset.tokens {
+			record := NewTokenRecord(assetId, token)
+			if err := batch.Set(store.TokenKey(assetId, token.Id), &record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func LoadToken(s *store.Store, assetId string, id string) (*TokenRecord, error) {
+	var record TokenRecord
+	if err := s.Get(store.TokenKey(assetId, id), &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// LoadTokenRecords reads back the record of every stored token of a
+// collection.
+func LoadTokenRecords(s *store.Store, assetId string) ([]*TokenRecord, error) {
+	var records []*TokenRecord
+	err := s.Iterate(store.TokenPrefix(assetId), func(key []byte, value []byte) error {
+		var record TokenRecord
+		if err := json.Unmarshal(value, &record); err != nil {
+			return err
+		}
+		records = append(records, &record)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// LoadTokens reads back every stored token of a collection, so it can be
+// scored again without being refetched.
+func LoadTokens(s *store.Store, assetId string) ([]*Token, error) {
+	records, err := LoadTokenRecords(s, assetId)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make([]*Token, len(records))
+	for i, record := range records {
+		tokens[i] = record.Token()
+	}
+	return tokens, nil
+}
@@ -1,41 +1,283 @@
 package collection
 
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"sync"
+)
+
+// Synthetic trait values added once every token is counted: TraitMissing
+// counts the tokens without a category, and the TraitCount category counts
+// tokens by how many categories they have.
+const (
+	TraitMissing = "None"
+	TraitCount   = "Trait Count"
+)
+
 type Item struct {
-	name map[string]int
+	name map[string]float64
 }
 
+// Trait counts the values of every trait category across a collection.
+// Index is the number of tokens folded in and Total the number of tokens the
+// crawl set out to read. Units is the number of copies those tokens stand
+// for, which only differs from Index for ERC-1155 collections where a token
+// counts once per unit of its supply, so the counts are kept as float64 to
+// hold supplies of any size. Numeric trait categories are kept apart from
+// the categorical ones, as ranges.
 type Trait struct {
 	Counter map[string]*Item
+	Numeric map[string]*NumericTrait
 
 	Index int
+	Total int
+	Units float64
+
+	mu sync.Mutex
+}
+
+// traitJSON is the encoding of a Trait.
+type traitJSON struct {
+	Counter map[string]map[string]float64 `json:"counter"`
+	Numeric map[string]*numericJSON       `json:"numeric,omitempty"`
+	Index   int                           `json:"index"`
+	Total   int                           `json:"total"`
+	Units   float64                       `json:"units,omitempty"`
 }
 
 func NewTrait() *Trait {
 	var trait Trait
 	trait.Counter = make(map[string]*Item)
+	trait.Numeric = make(map[string]*NumericTrait)
 	return &trait
 }
 
 func NewItem() *Item {
 	t := new(Item)
-	t.name = make(map[string]int)
+	t.name = make(map[string]float64)
 	return t
 }
 
+// Coverage is the share of the collection's tokens the counts are built from.
+func (t *Trait) Coverage() float64 {
+	if t.Total == 0 {
+		return 0
+	}
+	return float64(t.Index) / float64(t.Total)
+}
+
+// Count is the number of tokens holding value for the trait category.
+func (t *Trait) Count(category string, value string) float64 {
+	item, ok := t.Counter[category]
+	if !ok {
+		return 0
+	}
+	return item.name[value]
+}
+
+// Values returns a copy of the value counts of a trait category.
+func (t *Trait) Values(category string) map[string]float64 {
+	values := make(map[string]float64)
+	if item, ok := t.Counter[category]; ok {
+		for value, count := range item.name {
+			values[value] = count
+		}
+	}
+	return values
+}
+
+// Frequency is the share of the counted tokens holding value for the trait
+// category.
+func (t *Trait) Frequency(category string, value string) float64 {
+	units := t.units()
+	if units == 0 {
+		return 0
+	}
+	return t.Count(category, value) / units
+}
+
+// units is the number of copies the counts are built from.
+func (t *Trait) units() float64 {
+	if t.Units == 0 {
+		return float64(t.Index)
+	}
+	return t.Units
+}
+
+// Entropy is the Shannon entropy, in bits, of the collection's trait values
+// summed over every category.
+func (t *Trait) Entropy() float64 {
+	entropy := 0.0
+	for category, item := range t.Counter {
+		for value := range item.name {
+			if f := t.Frequency(category, value); f > 0 {
+				entropy -= f * math.Log2(f)
+			}
+		}
+	}
+	if units := t.units(); units > 0 {
+		for _, numeric := range t.Numeric {
+			for _, bucket := range numeric.Histogram(HistogramBuckets) {
+				if f := bucket.Count / units; f > 0 {
+					entropy -= f * math.Log2(f)
+				}
+			}
+		}
+	}
+	return entropy
+}
+
+func (t *Trait) MarshalJSON() ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	encoded := traitJSON{
+		Counter: make(map[string]map[string]float64, len(t.Counter)),
+		Index:   t.Index,
+		Total:   t.Total,
+		Units:   t.Units,
+	}
+	for category, item := range t.Counter {
+		encoded.Counter[category] = item.name
+	}
+	if len(t.Numeric) > 0 {
+		encoded.Numeric = make(map[string]*numericJSON, len(t.Numeric))
+		for category, numeric := range t.Numeric {
+			encoded.Numeric[category] = numeric.encode()
+		}
+	}
+	return json.Marshal(&encoded)
+}
+
+func (t *Trait) UnmarshalJSON(data []byte) error {
+	var decoded traitJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	t.Counter = make(map[string]*Item, len(decoded.Counter))
+	for category, values := range decoded.Counter {
+		item := NewItem()
+		for value, count := range values {
+			item.name[value] = count
+		}
+		t.Counter[category] = item
+	}
+	t.Numeric = make(map[string]*NumericTrait, len(decoded.Numeric))
+	for category, encoded := range decoded.Numeric {
+		numeric, err := decodeNumericTrait(encoded)
+		if err != nil {
+			return err
+		}
+		t.Numeric[category] = numeric
+	}
+	t.Index = decoded.Index
+	t.Total = decoded.Total
+	t.Units = decoded.Units
+	return nil
+}
+
+// Finalize adds the synthetic counts once every token of the crawl has been
+// folded in: the tokens missing each categorical trait, and the number of
+// categories of every token. Numeric traits keep to their ranges.
+func (t *Trait) Finalize(tokens []*Token) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	categories := make(map[string]bool, len(t.Counter))
+	for category := range t.Counter {
+		if t.Numeric[category] == nil {
+			categories[category] = true
+		}
+	}
+
+	counter := NewItem()
+	for _, token := range tokens {
+		held := heldCategories(token.Attributes)
+		for category := range categories {
+			if held[category] {
+				continue
+			}
+			t.Counter[category].name[TraitMissing] += token.weight()
+		}
+		counter.name[strconv.Itoa(len(held))] += token.weight()
+	}
+	if len(tokens) > 0 {
+		t.Counter[TraitCount] = counter
+	}
+}
+
+// Complete adds to the attributes of a token the synthetic ones a finalized
+// trait counts it under, so rarity accounts for them.
+func (t *Trait) Complete(attributes []Attribute) []Attribute {
+	if _, ok := t.Counter[TraitCount]; !ok {
+		return attributes
+	}
+
+	held := heldCategories(attributes)
+	completed := make([]Attribute, len(attributes), len(attributes)+len(t.Counter)+1)
+	copy(completed, attributes)
+	for category, item := range t.Counter {
+		if category == TraitCount || held[category] {
+			continue
+		}
+		if _, ok := item.name[TraitMissing]; ok {
+			completed = append(completed, Attribute{Trait: category, Value: StringValue(TraitMissing)})
+		}
+	}
+	return append(completed, Attribute{Trait: TraitCount, Value: StringValue(strconv.Itoa(len(held)))})
+}
+
+func heldCategories(attributes []Attribute) map[string]bool {
+	held := make(map[string]bool, len(attributes))
+	for _, attribute := range attributes {
+		held[attribute.Trait] = true
+	}
+	return held
+}
+
 func BuildTrait(attributes *[]Attribute, trait *Trait) {
+	BuildWeightedTrait(attributes, trait, 1)
+}
+
+// BuildWeightedTrait folds in a token held in weight copies. Numeric
+// attributes are added to the range of their category.
+func BuildWeightedTrait(attributes *[]Attribute, trait *Trait, weight float64) {
+	trait.mu.Lock()
+	defer trait.mu.Unlock()
+
 	counter := (*trait).Counter
 
 	for j := 0; j < len(*attributes); j++ {
-		trait := (*attributes)[j].Trait
-		value := (*attributes)[j].Value
+		attribute := (*attributes)[j]
+
+		if number, ok := attribute.Numeric(); ok {
+			numeric := trait.Numeric[attribute.Trait]
+			if numeric == nil {
+				numeric = NewNumericTrait(attribute.DisplayType)
+				trait.Numeric[attribute.Trait] = numeric
+			}
+
+			maxValue := 0.0
+			if attribute.MaxValue != nil {
+				maxValue, _ = attribute.MaxValue.Float()
+			}
+			numeric.add(number, maxValue, weight)
+			continue
+		}
+
+		trait := attribute.Trait
+		value := attribute.Value.String()
 
 		if counter[trait] == nil {
 			item := NewItem()
 			counter[trait] = item
 		}
 
-		counter[trait].name[value]++
+		counter[trait].name[value] += weight
 	}
 
 	(*trait).Index++
+	(*trait).Units += weight
 }
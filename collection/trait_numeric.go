@@ -0,0 +1,143 @@
+package collection
+
+import (
+	"math"
+	"strconv"
+)
+
+// HistogramBuckets is the number of equal width buckets a numeric trait is
+// spread over, for both its histogram and the frequency of its values.
+const HistogramBuckets = 10
+
+// NumericTrait aggregates a numeric trait category as a range of values
+// rather than one bucket per value. Counts are weighted like those of Trait.
+type NumericTrait struct {
+	DisplayType string
+	Min         float64
+	Max         float64
+	// MaxValue is the largest max_value declared by the tokens, if any.
+	MaxValue float64
+	Count    float64
+	Sum      float64
+
+	values map[float64]float64
+}
+
+// Bucket is one bar of a histogram. High is exclusive but for the last
+// bucket.
+type Bucket struct {
+	Low   float64 `json:"low"`
+	High  float64 `json:"high"`
+	Count float64 `json:"count"`
+}
+
+// numericJSON is the encoding of a NumericTrait.
+type numericJSON struct {
+	DisplayType string             `json:"display_type,omitempty"`
+	Min         float64            `json:"min"`
+	Max         float64            `json:"max"`
+	MaxValue    float64            `json:"max_value,omitempty"`
+	Count       float64            `json:"count"`
+	Sum         float64            `json:"sum"`
+	Values      map[string]float64 `json:"values"`
+}
+
+func NewNumericTrait(displayType string) *NumericTrait {
+	return &NumericTrait{
+		DisplayType: displayType,
+		values:      make(map[float64]float64),
+	}
+}
+
+func (n *NumericTrait) add(value float64, maxValue float64, weight float64) {
+	if n.Count == 0 || value < n.Min {
+		n.Min = value
+	}
+	if n.Count == 0 || value > n.Max {
+		n.Max = value
+	}
+	if maxValue > n.MaxValue {
+		n.MaxValue = maxValue
+	}
+	n.Count += weight
+	n.Sum += value * weight
+	n.values[value] += weight
+}
+
+func (n *NumericTrait) Mean() float64 {
+	if n.Count == 0 {
+		return 0
+	}
+	return n.Sum / n.Count
+}
+
+// Histogram spreads the values over equal width buckets between Min and
+// Max, a single one when every value is the same.
+func (n *NumericTrait) Histogram(buckets int) []Bucket {
+	if n.Count == 0 {
+		return nil
+	}
+	if n.Min == n.Max {
+		return []Bucket{{Low: n.Min, High: n.Max, Count: n.Count}}
+	}
+
+	width := (n.Max - n.Min) / float64(buckets)
+	histogram := make([]Bucket, buckets)
+	for i := range histogram {
+		histogram[i].Low = n.Min + float64(i)*width
+		histogram[i].High = n.Min + float64(i+1)*width
+	}
+	histogram[buckets-1].High = n.Max
+
+	for value, count := range n.values {
+		histogram[n.bucket(value, buckets)].Count += count
+	}
+	return histogram
+}
+
+func (n *NumericTrait) bucket(value float64, buckets int) int {
+	if n.Max == n.Min {
+		return 0
+	}
+	i := int(math.Floor((value - n.Min) / (n.Max - n.Min) * float64(buckets)))
+	if i >= buckets {
+		return buckets - 1
+	}
+	if i < 0 {
+		return 0
+	}
+	return i
+}
+
+func (n *NumericTrait) encode() *numericJSON {
+	encoded := numericJSON{
+		DisplayType: n.DisplayType,
+		Min:         n.Min,
+		Max:         n.Max,
+		MaxValue:    n.MaxValue,
+		Count:       n.Count,
+		Sum:         n.Sum,
+		Values:      make(map[string]float64, len(n.values)),
+	}
+	for value, count := range n.values {
+		encoded.Values[strconv.FormatFloat(value, 'g', -1, 64)] = count
+	}
+	return &encoded
+}
+
+func decodeNumericTrait(encoded *numericJSON) (*NumericTrait, error) {
+	n := NewNumericTrait(encoded.DisplayType)
+	n.Min = encoded.Min
+	n.Max = encoded.Max
+	n.MaxValue = encoded.MaxValue
+	n.Count = encoded.Count
+	n.Sum = encoded.Sum
+	for text, count := range encoded.Values {
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, err
+		}
+		n.values[value] = count
+	}
+	return n, nil
+}
@@ -0,0 +1,257 @@
+package collection
+
+import (
+	"container/heap"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/levelabs/level-go/store"
+)
+
+// Results of the last sequencing of a collection.
+const (
+	ResultPending = ""
+	ResultOk      = "ok"
+	ResultFailed  = "failed"
+)
+
+// WaitlistEntry is the stored state of a tracked collection: how often it is
+// refreshed, whether it is paused, when it runs next, how many times in a row
+// it failed and how its last run went. Paused collections stay tracked but
+// aren't queued.
+type WaitlistEntry struct {
+	Collection  string        `json:"collection"`
+	Policy      RefreshPolicy `json:"policy"`
+	Paused      bool          `json:"paused,omitempty"`
+	Priority    int64         `json:"priority"`
+	LastSuccess time.Time     `json:"last_success,omitempty"`
+	Attempts    int           `json:"attempts"`
+
+	LastRun      time.Time     `json:"last_run,omitempty"`
+	LastDuration time.Duration `json:"last_duration,omitempty"`
+	LastTokens   int           `json:"last_tokens,omitempty"`
+	LastResult   string        `json:"last_result,omitempty"`
+	LastError    string        `json:"last_error,omitempty"`
+}
+
+func NewWaitlistEntry(asset *Asset) WaitlistEntry {
+	return WaitlistEntry{
+		Collection:   asset.Id(),
+		Policy:       asset.policy,
+		Paused:       asset.paused,
+		Priority:     asset.priority,
+		LastSuccess:  asset.lastSuccess,
+		Attempts:     asset.attempts,
+		LastRun:      asset.lastRun,
+		LastDuration: asset.lastDuration,
+		LastTokens:   asset.lastTokens,
+		LastResult:   asset.lastResult,
+		LastError:    asset.lastError,
+	}
+}
+
+// Asset rebuilds the waitlisted asset, from the collection's stored snapshot
+// when it has one so its uri and capabilities aren't probed again.
+func (entry *WaitlistEntry) Asset(s *store.Store) (*Asset, error) {
+	asset, err := LoadAsset(s, entry.Collection)
+	if err == store.ErrNotFound {
+		chainId, address, err := ParseAssetId(entry.Collection)
+		if err != nil {
+			return nil, err
+		}
+		asset = NewAsset(chainId, address, 0, 0)
+	} else if err != nil {
+		return nil, err
+	}
+
+	asset.policy = entry.Policy
+	if asset.policy.Class == "" {
+		asset.policy = DefaultRefreshPolicy()
+	}
+	asset.paused = entry.Paused
+	asset.priority = entry.Priority
+	asset.lastSuccess = entry.LastSuccess
+	asset.attempts = entry.Attempts
+	asset.lastRun = entry.LastRun
+	asset.lastDuration = entry.LastDuration
+	asset.lastTokens = entry.LastTokens
+	asset.lastResult = entry.LastResult
+	asset.lastError = entry.LastError
+	return asset, nil
+}
+
+func SaveWaitlistEntry(s *store.Store, asset *Asset) error {
+	entry := NewWaitlistEntry(asset)
+	return s.Set(store.WaitlistKey(asset.Id()), &entry)
+}
+
+func DeleteWaitlistEntry(s *store.Store, assetId string) error {
+	return s.Delete(store.WaitlistKey(assetId))
+}
+
+// TrackAsset stores a waitlist entry for a collection, due now with policy,
+// resuming it if paused and taking it off the dead-letter list. A running
+// manager only picks it up on its next start.
+func TrackAsset(s *store.Store, assetId string, policy RefreshPolicy) (*WaitlistEntry, error) {
+	id, err := NormalizeAssetId(assetId)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry WaitlistEntry
+	err = s.Get(store.WaitlistKey(id), &entry)
+	if err != nil && err != store.ErrNotFound {
+		return nil, err
+	}
+	entry.Collection = id
+	entry.Policy = policy
+	entry.Paused = false
+	entry.Priority = time.Now().UnixNano()
+
+	err = s.WriteBatch(func(batch *store.Batch) error {
+		if err := batch.Delete(store.DeadLetterKey(id)); err != nil {
+			return err
+		}
+		return batch.Set(store.WaitlistKey(id), &entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// UntrackAsset deletes the waitlist entry or dead letter of a collection.
+func UntrackAsset(s *store.Store, assetId string) error {
+	id, err := NormalizeAssetId(assetId)
+	if err != nil {
+		return err
+	}
+
+	var entry WaitlistEntry
+	if err := s.Get(store.WaitlistKey(id), &entry); err == store.ErrNotFound {
+		if _, err := LoadDeadLetter(s, id); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+	return forgetAsset(s, id)
+}
+
+// forgetAsset deletes every waitlist record of a collection, keeping it
+// marked as seeded so the configuration doesn't track it again.
+func forgetAsset(s *store.Store, id string) error {
+	return s.WriteBatch(func(batch *store.Batch) error {
+		if err := batch.Delete(store.WaitlistKey(id)); err != nil {
+			return err
+		}
+		if err := batch.Delete(store.DeadLetterKey(id)); err != nil {
+			return err
+		}
+		return batch.Set(store.SeededKey(id), time.Now())
+	})
+}
+
+// seeded tells whether a configured collection was tracked before.
+func seeded(s *store.Store, id string) (bool, error) {
+	var at time.Time
+	err := s.Get(store.SeededKey(id), &at)
+	if err == store.ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// LoadWaitlist reads back the entry of every tracked collection.
+func LoadWaitlist(s *store.Store) ([]*WaitlistEntry, error) {
+	var entries []*WaitlistEntry
+	err := s.Iterate(store.WaitlistPrefix(), func(key []byte, value []byte) error {
+		var entry WaitlistEntry
+		if err := json.Unmarshal(value, &entry); err != nil {
+			return err
+		}
+		entries = append(entries, &entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// RestoreWaitlist rebuilds the waitlist from its stored entries. Seed assets,
+// keyed by asset id, are tracked with their refresh policy, due right away,
+// the first time they are seen only: once removed or dead-lettered they stay
+// that way across restarts.
+func RestoreWaitlist(s *store.Store, seeds map[string]RefreshPolicy) (*Waitlist, error) {
+	entries, err := LoadWaitlist(s)
+	if err != nil {
+		return nil, err
+	}
+
+	cq := make(PriorityQueue, 0, len(entries)+len(seeds))
+	tracked := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		asset, err := entry.Asset(s)
+		if err != nil {
+			log.Printf("[WARN]: Skipping waitlist entry %s %s", entry.Collection, err)
+			continue
+		}
+		tracked[asset.Id()] = true
+		if asset.paused {
+			continue
+		}
+		asset.index = len(cq)
+		cq = append(cq, asset)
+	}
+
+	now := time.Now().UnixNano()
+	for id, policy := range seeds {
+		chainId, address, err := ParseAssetId(id)
+		if err != nil {
+			log.Printf("[WARN]: Skipping asset %s %s", id, err)
+			continue
+		}
+		id := AssetId(chainId, address)
+
+		done, err := seeded(s, id)
+		if err != nil {
+			return nil, err
+		}
+		if done {
+			continue
+		}
+		if !tracked[id] {
+			if _, err := LoadDeadLetter(s, id); err == nil {
+				tracked[id] = true
+			} else if err != store.ErrNotFound {
+				return nil, err
+			}
+		}
+		if tracked[id] {
+			if err := s.Set(store.SeededKey(id), time.Now()); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		asset := NewAsset(chainId, address, now, len(cq))
+		asset.policy = policy
+		entry := NewWaitlistEntry(asset)
+		err = s.WriteBatch(func(batch *store.Batch) error {
+			if err := batch.Set(store.WaitlistKey(id), &entry); err != nil {
+				return err
+			}
+			return batch.Set(store.SeededKey(id), time.Now())
+		})
+		if err != nil {
+			return nil, err
+		}
+		cq = append(cq, asset)
+		tracked[asset.Id()] = true
+	}
+
+	heap.Init(&cq)
+	return NewWaitlist(&cq), nil
+}
@@ -0,0 +1,84 @@
+package collection
+
+import (
+	"errors"
+	"testing"
+
+	badger "github.com/dgraph-io/badger/v3"
+
+	"github.com/levelabs/level-go/store"
+)
+
+func newTestStore(t *testing.T) *store.Store {
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return store.NewStore(db)
+}
+
+func restoredIds(t *testing.T, s *store.Store, seeds map[string]RefreshPolicy) map[string]bool {
+	waitlist, err := RestoreWaitlist(s, seeds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make(map[string]bool)
+	for id := range waitlist.queued {
+		ids[id] = true
+	}
+	return ids
+}
+
+func TestRestoreWaitlistSeedsOnce(t *testing.T) {
+	s := newTestStore(t)
+	kept := AssetId(1, "0x0000000000000000000000000000000000000001")
+	removed := AssetId(1, "0x0000000000000000000000000000000000000002")
+	dead := AssetId(1, "0x0000000000000000000000000000000000000003")
+	seeds := map[string]RefreshPolicy{
+		kept:    DefaultRefreshPolicy(),
+		removed: DefaultRefreshPolicy(),
+	}
+
+	ids := restoredIds(t, s, seeds)
+	if !ids[kept] || !ids[removed] {
+		t.Fatalf("first restore = %v, want both seeds", ids)
+	}
+
+	if err := UntrackAsset(s, removed); err != nil {
+		t.Fatal(err)
+	}
+	if ids := restoredIds(t, s, seeds); !ids[kept] || ids[removed] {
+		t.Errorf("restore after untrack = %v, want only %s", ids, kept)
+	}
+
+	// a seed dead-lettered before it was ever marked isn't tracked again
+	chainId, address, _ := ParseAssetId(dead)
+	if err := deadLetter(s, NewAsset(chainId, address, 0, 0), errors.New("failed")); err != nil {
+		t.Fatal(err)
+	}
+	seeds[dead] = DefaultRefreshPolicy()
+	if ids := restoredIds(t, s, seeds); ids[dead] {
+		t.Errorf("restore = %v, tracked dead-lettered seed %s again", ids, dead)
+	}
+	if _, err := LoadDeadLetter(s, dead); err != nil {
+		t.Errorf("dead letter of %s = %v, want kept", dead, err)
+	}
+}
+
+func TestTrackAssetResumes(t *testing.T) {
+	s := newTestStore(t)
+	asset := NewAsset(1, "0x0000000000000000000000000000000000000001", 0, 0)
+	asset.paused = true
+	if err := SaveWaitlistEntry(s, asset); err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := TrackAsset(s, asset.Id(), DefaultRefreshPolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Paused {
+		t.Error("TrackAsset kept the collection paused")
+	}
+}
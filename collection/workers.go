@@ -0,0 +1,72 @@
+package collection
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	defaultWorkers  = 4
+	defaultIdleWait = 5 * time.Second
+)
+
+// SequenceHandler receives every asset sequenced successfully, once stored,
+// from the worker which sequenced it and while that worker still holds its
+// lease.
+type SequenceHandler func(asset *Asset)
+
+// WorkerPool drains the waitlist of a manager with several workers, so a
+// slow collection only holds up its own worker.
+type WorkerPool struct {
+	manager *Manager
+
+	Workers int
+	// IdleWait is how long a worker sleeps when nothing is due.
+	IdleWait time.Duration
+}
+
+func NewWorkerPool(manager *Manager, workers int) *WorkerPool {
+	if workers < 1 {
+		workers = defaultWorkers
+	}
+	return &WorkerPool{
+		manager:  manager,
+		Workers:  workers,
+		IdleWait: defaultIdleWait,
+	}
+}
+
+// Run starts the workers and blocks until ctx is done and every worker has
+// finished its current collection.
+func (pool *WorkerPool) Run(ctx context.Context, handle SequenceHandler) {
+	var wg sync.WaitGroup
+	for i := 0; i < pool.Workers; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			pool.work(ctx, worker, handle)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func (pool *WorkerPool) work(ctx context.Context, worker int, handle SequenceHandler) {
+	for ctx.Err() == nil {
+		_, err := pool.manager.RunSequence(handle)
+		switch err {
+		case nil:
+			continue
+		case errEmptyWaitlist, errWaitlistNotDue:
+		default:
+			log.Printf("[WARN]: Worker %d sequencing %s", worker, err)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(pool.IdleWait):
+		}
+	}
+}
@@ -7,10 +7,11 @@ import (
 )
 
 func UnmarshalJSON(data io.ReadCloser, item interface{}) error {
+	defer data.Close()
+
 	buf, err := ioutil.ReadAll(data)
 	if err != nil {
 		return err
 	}
-	json.Unmarshal(buf, &item)
-	return nil
+	return json.Unmarshal(buf, &item)
 }
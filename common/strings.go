@@ -1,13 +1,12 @@
 package common
 
 import (
-	"strconv"
 	"strings"
 	"unicode"
 )
 
-func BuildUrl(url string, i int) string {
-	return strings.Join([]string{url, strconv.Itoa(i)}, "")
+func BuildUrl(url string, id string) string {
+	return strings.Join([]string{url, id}, "")
 }
 
 func TrimRightNumber(url string) string {
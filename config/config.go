@@ -0,0 +1,241 @@
+package config
+
+import (
+	"time"
+
+	"github.com/levelabs/level-go/collection"
+)
+
+// Config is everything the service reads at startup. It is built from the
+// defaults, then a YAML file, then LEVEL_* environment variables and then
+// command line flags, each overriding the previous ones.
+type Config struct {
+	Listen string `yaml:"listen"`
+	Admin  Admin  `yaml:"admin"`
+
+	Chains  map[uint64]Chain `yaml:"chains"`
+	IPFS    IPFS             `yaml:"ipfs"`
+	Arweave Arweave          `yaml:"arweave"`
+
+	Storage  Storage  `yaml:"storage"`
+	Cache    Cache    `yaml:"cache"`
+	Workers  Workers  `yaml:"workers"`
+	Schedule Schedule `yaml:"schedule"`
+
+	Collections []Collection `yaml:"collections"`
+}
+
+// Admin holds the bearer token of the admin API, which stays disabled while
+// the token is empty.
+type Admin struct {
+	Token string `yaml:"token"`
+}
+
+// Chain holds the endpoints of one EVM chain. Ws is optional and enables
+// live subscriptions. Confirmations defaults to the usual finality of known
+// chains.
+type Chain struct {
+	Rpc           string `yaml:"rpc"`
+	Ws            string `yaml:"ws"`
+	Confirmations uint64 `yaml:"confirmations"`
+}
+
+// IPFS holds the address of the IPFS node API, and the HTTP gateways tried
+// when the node can't serve a file.
+type IPFS struct {
+	Uri      string   `yaml:"uri"`
+	Gateways []string `yaml:"gateways"`
+}
+
+type Arweave struct {
+	Gateway string `yaml:"gateway"`
+}
+
+type Storage struct {
+	Path string `yaml:"path"`
+}
+
+// Cache sizes the ristretto cache in front of the API, see ristretto.Config.
+type Cache struct {
+	Counters    int64 `yaml:"counters"`
+	MaxCost     int64 `yaml:"max_cost"`
+	BufferItems int64 `yaml:"buffer_items"`
+}
+
+// Workers sets how many collections are sequenced at once, and how many
+// tokens of a collection are fetched at once for each uri scheme.
+type Workers struct {
+	Sequence int            `yaml:"sequence"`
+	Crawl    map[string]int `yaml:"crawl"`
+}
+
+// Schedule sets how long an idle sequencing worker waits for a collection to
+// be due, and how often stored collections are checked for ownership
+// indexing.
+type Schedule struct {
+	Idle      time.Duration `yaml:"idle"`
+	Ownership time.Duration `yaml:"ownership"`
+}
+
+// Collection is a collection tracked from the first start. Class is one of
+// hot, normal or archival, and the durations default to those of the class.
+type Collection struct {
+	Id           string        `yaml:"id"`
+	Class        string        `yaml:"class"`
+	Interval     time.Duration `yaml:"interval"`
+	MaxStaleness time.Duration `yaml:"max_staleness"`
+
+	// DeployBlock is where ownership indexing starts when the node isn't an
+	// archive node and can't locate the deployment itself.
+	DeployBlock uint64 `yaml:"deploy_block"`
+
+	// TokenRange lists the token ids of a collection which is neither
+	// enumerable nor numbered from zero or one.
+	TokenRange *TokenRange `yaml:"token_range"`
+}
+
+// TokenRange is a span of token ids, with End exclusive.
+type TokenRange struct {
+	Start int64 `yaml:"start"`
+	End   int64 `yaml:"end"`
+}
+
+// chainConfirmations are the confirmations waited for on known chains when
+// none are configured.
+var chainConfirmations = map[uint64]uint64{
+	collection.ChainMainnet:  12,
+	collection.ChainPolygon:  128,
+	collection.ChainArbitrum: 20,
+	collection.ChainBase:     20,
+}
+
+// Default is the configuration used when nothing overrides it. No chain is
+// configured by default: their RPC urls come from the file or environment.
+// Neither is any collection, see defaultCollections.
+func Default() *Config {
+	return &Config{
+		Listen: ":8080",
+		Chains: map[uint64]Chain{},
+		IPFS: IPFS{
+			Uri:      "localhost:5001",
+			Gateways: []string{"https://ipfs.io", "https://cloudflare-ipfs.com"},
+		},
+		Arweave: Arweave{
+			Gateway: "https://arweave.net",
+		},
+		Storage: Storage{
+			Path: "/tmp/badger",
+		},
+		Cache: Cache{
+			Counters:    1e7,     // number of keys to track frequency of (10M).
+			MaxCost:     1 << 30, // maximum cost of cache (1GB).
+			BufferItems: 64,      // number of keys per Get buffer.
+		},
+		Workers: Workers{
+			Sequence: 4,
+			Crawl: map[string]int{
+				"ipfs":    8,
+				"http":    16,
+				"arweave": 8,
+				"token":   8,
+			},
+		},
+		Schedule: Schedule{
+			Idle:      5 * time.Second,
+			Ownership: time.Minute,
+		},
+	}
+}
+
+// defaultCollections are tracked when no list of collections is configured
+// and mainnet is.
+var defaultCollections = []Collection{
+	{Id: "0xbc4ca0eda7647a8ab7c2061c2e118a18a936f13d", Class: collection.ClassNormal},
+}
+
+// ClientConfig is the configuration of the clients of the manager.
+func (c *Config) ClientConfig() collection.ClientConfig {
+	chains := make(map[uint64]collection.ChainConfig, len(c.Chains))
+	for chainId, chain := range c.Chains {
+		chains[chainId] = collection.ChainConfig{
+			EthUri:        chain.Rpc,
+			EthWsUri:      chain.Ws,
+			Confirmations: chain.Confirmations,
+		}
+	}
+
+	return collection.ClientConfig{
+		Chains:       chains,
+		IPFSUri:      c.IPFS.Uri,
+		IPFSGateways: c.IPFS.Gateways,
+		ArweaveUri:   c.Arweave.Gateway,
+	}
+}
+
+// CrawlConfig is the per scheme crawl concurrency of the manager.
+func (c *Config) CrawlConfig() collection.CrawlConfig {
+	crawl := collection.DefaultCrawlConfig()
+	for name, workers := range c.Workers.Crawl {
+		crawl.Concurrency[uriSchemes[name]] = workers
+	}
+	return crawl
+}
+
+// Seeds are the initial collections by asset id, with their refresh
+// policies. Collections are expected to have been validated.
+func (c *Config) Seeds() map[string]collection.RefreshPolicy {
+	seeds := make(map[string]collection.RefreshPolicy, len(c.Collections))
+	for _, tracked := range c.Collections {
+		id, _ := collection.NormalizeAssetId(tracked.Id)
+		policy, _ := tracked.Policy()
+		seeds[id] = policy
+	}
+	return seeds
+}
+
+// DeployBlocks are the configured deployment blocks by asset id.
+// Collections are expected to have been validated.
+func (c *Config) DeployBlocks() map[string]uint64 {
+	blocks := make(map[string]uint64)
+	for _, tracked := range c.Collections {
+		if tracked.DeployBlock == 0 {
+			continue
+		}
+		id, _ := collection.NormalizeAssetId(tracked.Id)
+		blocks[id] = tracked.DeployBlock
+	}
+	return blocks
+}
+
+// TokenRanges are the configured token ranges by asset id. Collections are
+// expected to have been validated.
+func (c *Config) TokenRanges() map[string]collection.TokenRange {
+	ranges := make(map[string]collection.TokenRange)
+	for _, tracked := range c.Collections {
+		if tracked.TokenRange == nil {
+			continue
+		}
+		id, _ := collection.NormalizeAssetId(tracked.Id)
+		ranges[id] = collection.TokenRange{Start: tracked.TokenRange.Start, End: tracked.TokenRange.End}
+	}
+	return ranges
+}
+
+// Policy is the refresh policy of the collection, its durations filled in
+// from its class.
+func (c Collection) Policy() (collection.RefreshPolicy, error) {
+	class := c.Class
+	if class == "" {
+		class = collection.ClassNormal
+	}
+
+	policy := collection.RefreshPolicy{
+		Class:        class,
+		Interval:     c.Interval,
+		MaxStaleness: c.MaxStaleness,
+	}
+	if err := policy.Validate(); err != nil {
+		return collection.RefreshPolicy{}, err
+	}
+	return policy, nil
+}
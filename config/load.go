@@ -0,0 +1,319 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+
+	"github.com/levelabs/level-go/collection"
+)
+
+var (
+	errListenMissing     = errors.New("listen address is empty")
+	errChainsMissing     = errors.New("no chain is configured")
+	errRpcMissing        = errors.New("rpc url is empty")
+	errEndpointInvalid   = errors.New("endpoint should be an http(s) or ws(s) url")
+	errGatewayInvalid    = errors.New("gateway should be an http(s) url")
+	errIPFSMissing       = errors.New("ipfs uri is empty")
+	errStorageMissing    = errors.New("storage path is empty")
+	errCacheInvalid      = errors.New("cache sizes should be positive")
+	errWorkersInvalid    = errors.New("worker counts should be positive")
+	errSchemeUnknown     = errors.New("crawl scheme should be ipfs, http, arweave or token")
+	errScheduleInvalid   = errors.New("schedule intervals should be positive")
+	errChainUnknown      = errors.New("collection is on a chain which isn't configured")
+	errCollectionTracked = errors.New("collection is listed twice")
+	errTokenRangeInvalid = errors.New("token range should start at 0 or above and end after its start")
+)
+
+// envPrefix starts the name of every environment variable read.
+const envPrefix = "LEVEL_"
+
+var uriSchemes = map[string]int{
+	"ipfs":    collection.UriIPFS,
+	"http":    collection.UriHttp,
+	"arweave": collection.UriArweave,
+	"token":   collection.UriToken,
+}
+
+// Flags registers the command line flags overriding the configuration.
+func Flags(flags *pflag.FlagSet) {
+	flags.StringP("config", "c", "", "YAML configuration file")
+	flags.String("listen", "", "Address the API listens on")
+	flags.String("storage", "", "Directory of the badger database")
+	flags.String("ipfs", "", "Address of the IPFS node API")
+	flags.Int("workers", 0, "Number of collections sequenced at once")
+}
+
+// Load builds the configuration from the defaults, the file named by the
+// config flag if any, the environment and the flags. References to
+// environment variables in the file, such as ${INFURA_KEY}, are expanded.
+// Only the storage path, which every command opens, is checked: commands
+// which sequence collections call Validate as well.
+func Load(flags *pflag.FlagSet) (*Config, error) {
+	config := Default()
+
+	path, err := flags.GetString("config")
+	if err != nil {
+		return nil, err
+	}
+	if path == "" {
+		path = os.Getenv(envPrefix + "CONFIG")
+	}
+	if path != "" {
+		if err := config.readFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.readEnv(); err != nil {
+		return nil, err
+	}
+	if err := config.readFlags(flags); err != nil {
+		return nil, err
+	}
+
+	for chainId, chain := range config.Chains {
+		if chain.Confirmations == 0 {
+			chain.Confirmations = chainConfirmations[chainId]
+			config.Chains[chainId] = chain
+		}
+	}
+
+	if config.Collections == nil {
+		if _, ok := config.Chains[collection.ChainMainnet]; ok {
+			config.Collections = append([]Collection(nil), defaultCollections...)
+		}
+	}
+
+	if config.Storage.Path == "" {
+		return nil, errStorageMissing
+	}
+	return config, nil
+}
+
+func (c *Config) readFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	// a list of collections, even an empty one, replaces the default one
+	if err := yaml.Unmarshal([]byte(os.ExpandEnv(string(data))), c); err != nil {
+		return fmt.Errorf("config %s: %w", path, err)
+	}
+	return nil
+}
+
+// readEnv applies the LEVEL_* variables: LISTEN, ADMIN_TOKEN, STORAGE_PATH,
+// IPFS_URI, IPFS_GATEWAYS (comma separated), ARWEAVE_GATEWAY,
+// CACHE_MAX_COST, WORKERS, and CHAIN_<id>_RPC and CHAIN_<id>_WS, which also
+// configure chains missing from the file.
+func (c *Config) readEnv() error {
+	fields := map[string]*string{
+		"LISTEN":          &c.Listen,
+		"ADMIN_TOKEN":     &c.Admin.Token,
+		"STORAGE_PATH":    &c.Storage.Path,
+		"IPFS_URI":        &c.IPFS.Uri,
+		"ARWEAVE_GATEWAY": &c.Arweave.Gateway,
+	}
+	for name, field := range fields {
+		if value, ok := os.LookupEnv(envPrefix + name); ok {
+			*field = value
+		}
+	}
+
+	if value, ok := os.LookupEnv(envPrefix + "IPFS_GATEWAYS"); ok {
+		c.IPFS.Gateways = splitList(value)
+	}
+	if value, ok := os.LookupEnv(envPrefix + "CACHE_MAX_COST"); ok {
+		cost, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%sCACHE_MAX_COST: %w", envPrefix, err)
+		}
+		c.Cache.MaxCost = cost
+	}
+	if value, ok := os.LookupEnv(envPrefix + "WORKERS"); ok {
+		workers, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%sWORKERS: %w", envPrefix, err)
+		}
+		c.Workers.Sequence = workers
+	}
+
+	for _, variable := range os.Environ() {
+		name, value := variable, ""
+		if i := strings.IndexByte(variable, '='); i >= 0 {
+			name, value = variable[:i], variable[i+1:]
+		}
+		if !strings.HasPrefix(name, envPrefix+"CHAIN_") {
+			continue
+		}
+
+		fields := strings.SplitN(strings.TrimPrefix(name, envPrefix+"CHAIN_"), "_", 2)
+		if len(fields) != 2 || (fields[1] != "RPC" && fields[1] != "WS") {
+			continue
+		}
+		chainId, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		if c.Chains == nil {
+			c.Chains = make(map[uint64]Chain)
+		}
+		chain := c.Chains[chainId]
+		if fields[1] == "RPC" {
+			chain.Rpc = value
+		} else {
+			chain.Ws = value
+		}
+		c.Chains[chainId] = chain
+	}
+	return nil
+}
+
+// readFlags applies the flags set on the command line.
+func (c *Config) readFlags(flags *pflag.FlagSet) error {
+	fields := map[string]*string{
+		"listen":  &c.Listen,
+		"storage": &c.Storage.Path,
+		"ipfs":    &c.IPFS.Uri,
+	}
+	for name, field := range fields {
+		if !flags.Changed(name) {
+			continue
+		}
+		value, err := flags.GetString(name)
+		if err != nil {
+			return err
+		}
+		*field = value
+	}
+
+	if flags.Changed("workers") {
+		workers, err := flags.GetInt("workers")
+		if err != nil {
+			return err
+		}
+		c.Workers.Sequence = workers
+	}
+	return nil
+}
+
+// Validate checks every setting, naming the first invalid one.
+func (c *Config) Validate() error {
+	if c.Listen == "" {
+		return errListenMissing
+	}
+
+	if len(c.Chains) == 0 {
+		return errChainsMissing
+	}
+	for chainId, chain := range c.Chains {
+		if chain.Rpc == "" {
+			return fmt.Errorf("chain %d: %w", chainId, errRpcMissing)
+		}
+		if !validUrl(chain.Rpc, "http", "https", "ws", "wss") {
+			return fmt.Errorf("chain %d rpc: %w", chainId, errEndpointInvalid)
+		}
+		if chain.Ws != "" && !validUrl(chain.Ws, "ws", "wss") {
+			return fmt.Errorf("chain %d ws: %w", chainId, errEndpointInvalid)
+		}
+	}
+
+	if c.IPFS.Uri == "" {
+		return errIPFSMissing
+	}
+	for _, gateway := range c.IPFS.Gateways {
+		if !validUrl(gateway, "http", "https") {
+			return fmt.Errorf("ipfs gateway %s: %w", gateway, errGatewayInvalid)
+		}
+	}
+	if !validUrl(c.Arweave.Gateway, "http", "https") {
+		return fmt.Errorf("arweave gateway %s: %w", c.Arweave.Gateway, errGatewayInvalid)
+	}
+
+	if c.Storage.Path == "" {
+		return errStorageMissing
+	}
+	if c.Cache.Counters <= 0 || c.Cache.MaxCost <= 0 || c.Cache.BufferItems <= 0 {
+		return errCacheInvalid
+	}
+
+	if c.Workers.Sequence <= 0 {
+		return errWorkersInvalid
+	}
+	for name, workers := range c.Workers.Crawl {
+		if _, ok := uriSchemes[name]; !ok {
+			return fmt.Errorf("crawl workers %s: %w", name, errSchemeUnknown)
+		}
+		if workers <= 0 {
+			return fmt.Errorf("crawl workers %s: %w", name, errWorkersInvalid)
+		}
+	}
+
+	if c.Schedule.Idle <= 0 || c.Schedule.Ownership <= 0 {
+		return errScheduleInvalid
+	}
+	return c.validateCollections()
+}
+
+func (c *Config) validateCollections() error {
+	tracked := make(map[string]bool, len(c.Collections))
+	for _, listed := range c.Collections {
+		chainId, address, err := collection.ParseAssetId(listed.Id)
+		if err != nil {
+			return fmt.Errorf("collection %s: %w", listed.Id, err)
+		}
+		if _, ok := c.Chains[chainId]; !ok {
+			return fmt.Errorf("collection %s: %w", listed.Id, errChainUnknown)
+		}
+
+		id := collection.AssetId(chainId, address)
+		if tracked[id] {
+			return fmt.Errorf("collection %s: %w", listed.Id, errCollectionTracked)
+		}
+		tracked[id] = true
+
+		if _, err := listed.Policy(); err != nil {
+			return fmt.Errorf("collection %s: %w", listed.Id, err)
+		}
+		if listed.Interval < 0 || listed.MaxStaleness < 0 {
+			return fmt.Errorf("collection %s: %w", listed.Id, errScheduleInvalid)
+		}
+		if r := listed.TokenRange; r != nil && (r.Start < 0 || r.End <= r.Start) {
+			return fmt.Errorf("collection %s: %w", listed.Id, errTokenRangeInvalid)
+		}
+	}
+	return nil
+}
+
+func validUrl(uri string, schemes ...string) bool {
+	u, err := url.Parse(uri)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	for _, scheme := range schemes {
+		if u.Scheme == scheme {
+			return true
+		}
+	}
+	return false
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+
+	"github.com/levelabs/level-go/collection"
+)
+
+func loadTest(t *testing.T, file string) (*Config, error) {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	Flags(flags)
+	if file != "" {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		if err := ioutil.WriteFile(path, []byte(file), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := flags.Set("config", path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	conf, err := Load(flags)
+	if err != nil {
+		return nil, err
+	}
+	return conf, conf.Validate()
+}
+
+func TestLoadChains(t *testing.T) {
+	if _, err := loadTest(t, ""); err != errChainsMissing {
+		t.Errorf("Load without chains = %v, want %v", err, errChainsMissing)
+	}
+
+	_, err := loadTest(t, "chains:\n  137:\n    ws: wss://polygon.example\n")
+	if !errors.Is(err, errRpcMissing) {
+		t.Errorf("Load of a chain without rpc = %v, want %v", err, errRpcMissing)
+	}
+
+	t.Setenv(envPrefix+"CHAIN_1_RPC", "https://mainnet.example")
+	t.Setenv(envPrefix+"CHAIN_137_RPC", "https://polygon.example")
+	conf, err := loadTest(t, "chains:\n  137:\n    ws: wss://polygon.example\n    confirmations: 64\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mainnet := conf.Chains[collection.ChainMainnet]
+	if mainnet.Rpc != "https://mainnet.example" || mainnet.Confirmations != 12 {
+		t.Errorf("mainnet = %+v, want its rpc from the environment and 12 confirmations", mainnet)
+	}
+	polygon := conf.Chains[collection.ChainPolygon]
+	if polygon.Rpc != "https://polygon.example" || polygon.Ws != "wss://polygon.example" || polygon.Confirmations != 64 {
+		t.Errorf("polygon = %+v, want the file merged with the environment", polygon)
+	}
+}
+
+func TestLoadDeployBlocks(t *testing.T) {
+	t.Setenv(envPrefix+"CHAIN_1_RPC", "https://mainnet.example")
+	conf, err := loadTest(t, "collections:\n"+
+		"  - id: \"0xBC4CA0EDA7647A8AB7C2061C2E118A18A936F13D\"\n    deploy_block: 12287507\n"+
+		"  - id: \"0x0000000000000000000000000000000000000001\"\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blocks := conf.DeployBlocks()
+	id := collection.AssetId(collection.ChainMainnet, "0xbc4ca0eda7647a8ab7c2061c2e118a18a936f13d")
+	if len(blocks) != 1 || blocks[id] != 12287507 {
+		t.Errorf("DeployBlocks = %v, want %s at 12287507", blocks, id)
+	}
+}
+
+func TestLoadTokenRanges(t *testing.T) {
+	t.Setenv(envPrefix+"CHAIN_1_RPC", "https://mainnet.example")
+	conf, err := loadTest(t, "collections:\n"+
+		"  - id: \"0x0000000000000000000000000000000000000001\"\n    token_range: {start: 1, end: 10001}\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id := collection.AssetId(collection.ChainMainnet, "0x0000000000000000000000000000000000000001")
+	if ranges := conf.TokenRanges(); len(ranges) != 1 || ranges[id] != (collection.TokenRange{Start: 1, End: 10001}) {
+		t.Errorf("TokenRanges = %v, want %s from 1 to 10001", ranges, id)
+	}
+
+	_, err = loadTest(t, "collections:\n"+
+		"  - id: \"0x0000000000000000000000000000000000000001\"\n    token_range: {start: 5, end: 5}\n")
+	if !errors.Is(err, errTokenRangeInvalid) {
+		t.Errorf("Load of an empty token range = %v, want %v", err, errTokenRangeInvalid)
+	}
+}
+
+func TestLoadStorageOnly(t *testing.T) {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	Flags(flags)
+	conf, err := Load(flags)
+	if err != nil {
+		t.Fatalf("Load without chains = %v, want the store settings alone to load", err)
+	}
+	if err := conf.Validate(); err != errChainsMissing {
+		t.Errorf("Validate without chains = %v, want %v", err, errChainsMissing)
+	}
+
+	if err := flags.Set("storage", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(flags); err != errStorageMissing {
+		t.Errorf("Load without storage = %v, want %v", err, errStorageMissing)
+	}
+}
+
+func TestLoadDefaultCollections(t *testing.T) {
+	t.Setenv(envPrefix+"CHAIN_137_RPC", "https://polygon.example")
+	conf, err := loadTest(t, "")
+	if err != nil {
+		t.Fatalf("Load with only polygon = %v", err)
+	}
+	if len(conf.Collections) != 0 {
+		t.Errorf("collections = %v, want none without mainnet", conf.Collections)
+	}
+
+	t.Setenv(envPrefix+"CHAIN_1_RPC", "https://mainnet.example")
+	conf, err = loadTest(t, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conf.Collections) != 1 || conf.Collections[0].Id != defaultCollections[0].Id {
+		t.Errorf("collections = %v, want the default ones", conf.Collections)
+	}
+
+	conf, err = loadTest(t, "collections: []\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conf.Collections) != 0 {
+		t.Errorf("collections = %v, want the empty list of the file", conf.Collections)
+	}
+}
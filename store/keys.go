@@ -0,0 +1,131 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Keys are laid out as "<kind>/<collection>/<id>", where the collection is
+// its "<chain id>:<address>" asset id in lower case, so every record of a
+// collection shares a prefix.
+const (
+	prefixCollection = "collection"
+	prefixToken      = "token"
+	prefixOwner      = "owner"
+	prefixHolding    = "holding"
+	prefixCheckpoint = "checkpoint"
+	prefixJournal    = "journal"
+	prefixSupply     = "supply"
+	prefixBalance    = "balance"
+	prefixWaitlist   = "waitlist"
+	prefixDeadLetter = "deadletter"
+	prefixSeeded     = "seeded"
+)
+
+func join(parts ...string) []byte {
+	return []byte(strings.Join(parts, "/"))
+}
+
+func normalize(part string) string {
+	return strings.ToLower(part)
+}
+
+// CollectionKey locates the snapshot of a collection.
+func CollectionKey(collection string) []byte {
+	return join(prefixCollection, normalize(collection))
+}
+
+// CollectionPrefix is shared by the snapshots of every collection.
+func CollectionPrefix() []byte {
+	return join(prefixCollection, "")
+}
+
+// TokenKey locates the metadata record of a single token.
+func TokenKey(collection string, id string) []byte {
+	return join(prefixToken, normalize(collection), id)
+}
+
+// TokenPrefix is shared by the token records of a collection.
+func TokenPrefix(collection string) []byte {
+	return join(prefixToken, normalize(collection), "")
+}
+
+// OwnerKey locates the current owner of a token.
+func OwnerKey(collection string, id string) []byte {
+	return join(prefixOwner, normalize(collection), id)
+}
+
+// HoldingKey marks a token as held by owner. The holdings of an owner share
+// the HoldingPrefix of the collection and owner.
+func HoldingKey(collection string, owner string, id string) []byte {
+	return join(prefixHolding, normalize(collection), normalize(owner), id)
+}
+
+func HoldingPrefix(collection string, owner string) []byte {
+	return join(prefixHolding, normalize(collection), normalize(owner), "")
+}
+
+// CheckpointKey locates the last block indexed for a collection.
+func CheckpointKey(collection string) []byte {
+	return join(prefixCheckpoint, normalize(collection))
+}
+
+// JournalKey locates the journal of an unconfirmed block. Block numbers are
+// zero padded so journals iterate in block order.
+func JournalKey(collection string, block uint64) []byte {
+	return join(prefixJournal, normalize(collection), fmt.Sprintf("%020d", block))
+}
+
+func JournalPrefix(collection string) []byte {
+	return join(prefixJournal, normalize(collection), "")
+}
+
+// SupplyKey locates the circulating supply of an ERC-1155 token id.
+func SupplyKey(collection string, id string) []byte {
+	return join(prefixSupply, normalize(collection), id)
+}
+
+func SupplyPrefix(collection string) []byte {
+	return join(prefixSupply, normalize(collection), "")
+}
+
+// SupplyCheckpointKey locates the last block read into the ERC-1155 supplies
+// of a collection.
+func SupplyCheckpointKey(collection string) []byte {
+	return join(prefixCheckpoint, prefixSupply, normalize(collection))
+}
+
+// BalanceKey locates the amount of an ERC-1155 token id held by holder.
+func BalanceKey(collection string, holder string, id string) []byte {
+	return join(prefixBalance, normalize(collection), normalize(holder), id)
+}
+
+func BalancePrefix(collection string, holder string) []byte {
+	return join(prefixBalance, normalize(collection), normalize(holder), "")
+}
+
+// WaitlistKey locates the waitlist entry of a tracked collection.
+func WaitlistKey(collection string) []byte {
+	return join(prefixWaitlist, normalize(collection))
+}
+
+// WaitlistPrefix is shared by the entries of every tracked collection.
+func WaitlistPrefix() []byte {
+	return join(prefixWaitlist, "")
+}
+
+// DeadLetterKey locates a collection taken off the waitlist after failing
+// too many times.
+func DeadLetterKey(collection string) []byte {
+	return join(prefixDeadLetter, normalize(collection))
+}
+
+func DeadLetterPrefix() []byte {
+	return join(prefixDeadLetter, "")
+}
+
+// SeededKey marks a configured collection as tracked once already, so it
+// isn't tracked again after being removed.
+func SeededKey(collection string) []byte {
+	return join(prefixSeeded, normalize(collection))
+}
@@ -0,0 +1,111 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+
+	badger "github.com/dgraph-io/badger/v3"
+)
+
+var (
+	ErrNotFound = errors.New("Key doesn't exist in the store")
+)
+
+// Store keeps JSON encoded values in badger.
+type Store struct {
+	db *badger.DB
+}
+
+type Batch struct {
+	wb *badger.WriteBatch
+}
+
+func Open(path string) (*Store, error) {
+	db, err := badger.Open(badger.DefaultOptions(path))
+	if err != nil {
+		return nil, err
+	}
+	return NewStore(db), nil
+}
+
+func NewStore(db *badger.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func (s *Store) Set(key []byte, value interface{}) error {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(key, bytes)
+	})
+}
+
+func (s *Store) Get(key []byte, value interface{}) error {
+	return s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if err == badger.ErrKeyNotFound {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, value)
+		})
+	})
+}
+
+func (s *Store) Delete(key []byte) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(key)
+	})
+}
+
+// Iterate calls fn with every key under prefix, in key order, and the raw
+// value stored at it. Neither slice may be kept after fn returns.
+func (s *Store) Iterate(prefix []byte, fn func(key []byte, value []byte) error) error {
+	return s.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			item := it.Item()
+			err := item.Value(func(val []byte) error {
+				return fn(item.Key(), val)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// WriteBatch groups many writes, committed together once fn returns.
+func (s *Store) WriteBatch(fn func(batch *Batch) error) error {
+	wb := s.db.NewWriteBatch()
+	defer wb.Cancel()
+
+	if err := fn(&Batch{wb: wb}); err != nil {
+		return err
+	}
+	return wb.Flush()
+}
+
+func (b *Batch) Set(key []byte, value interface{}) error {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return b.wb.Set(key, bytes)
+}
+
+func (b *Batch) Delete(key []byte) error {
+	return b.wb.Delete(key)
+}